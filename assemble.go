@@ -0,0 +1,375 @@
+package chip8
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Assemble converts CHIP-8 assembly into a ROM that can be loaded with New
+// or Load.
+//
+// Each line holds at most one instruction, written with the conventional
+// mnemonics such as CLS, RET, JP addr, LD Vx, NN and DRW Vx, Vy, N, followed
+// by comma separated operands. A line may start with a label of the form
+// "name:", which can be used in place of an address anywhere in the program.
+// Text following a ";" is a comment. DB and DW emit literal bytes and
+// big-endian words.
+//
+// Numbers are decimal, or hexadecimal with a 0x prefix. Labels are resolved
+// assuming the ROM is loaded at 0x200.
+// Errors identify the line at which they occurred.
+func Assemble(src string) ([]byte, error) {
+	a := &assembler{labels: make(map[string]uint16)}
+	if err := a.parse(src); err != nil {
+		return nil, err
+	}
+	var rom []byte
+	for _, s := range a.statements {
+		b, err := a.encode(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", s.line, err)
+		}
+		rom = append(rom, b...)
+	}
+	return rom, nil
+}
+
+// assembler holds the state of an assembly between passes.
+type assembler struct {
+	statements []statement
+	labels     map[string]uint16
+}
+
+// statement is a single instruction or directive from the source.
+type statement struct {
+	line     int
+	mnemonic string
+	operands []string
+}
+
+// parse splits the source into statements and records the address of each
+// label, so that forward references can be resolved when encoding.
+func (a *assembler) parse(src string) error {
+	addr := uint16(defaultLoadAddress)
+	for i, text := range strings.Split(src, "\n") {
+		line := i + 1
+		if j := strings.IndexByte(text, ';'); j >= 0 {
+			text = text[:j]
+		}
+		text = strings.TrimSpace(text)
+
+		if j := strings.IndexByte(text, ':'); j >= 0 {
+			label := strings.TrimSpace(text[:j])
+			if !isLabel(label) {
+				return fmt.Errorf("line %d: invalid label %q", line, label)
+			}
+			if _, ok := a.labels[label]; ok {
+				return fmt.Errorf("line %d: duplicate label %q", line, label)
+			}
+			a.labels[label] = addr
+			text = strings.TrimSpace(text[j+1:])
+		}
+		if text == "" {
+			continue
+		}
+
+		s := statement{line: line, mnemonic: strings.ToUpper(text)}
+		if j := strings.IndexAny(text, " \t"); j >= 0 {
+			s.mnemonic = strings.ToUpper(text[:j])
+			for _, op := range strings.Split(text[j+1:], ",") {
+				s.operands = append(s.operands, strings.TrimSpace(op))
+			}
+		}
+		a.statements = append(a.statements, s)
+
+		switch s.mnemonic {
+		case "DB":
+			addr += uint16(len(s.operands))
+		case "DW":
+			addr += uint16(2 * len(s.operands))
+		default:
+			addr += 2
+		}
+	}
+	return nil
+}
+
+// isLabel returns true iff name may be used as a label.
+// Names that could be mistaken for an operand, such as registers, are rejected.
+func isLabel(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	if _, ok := register(name); ok {
+		return false
+	}
+	switch strings.ToUpper(name) {
+	case "I", "DT", "ST", "K", "F", "HF", "B", "R":
+		return false
+	}
+	return true
+}
+
+// encode converts a statement to the bytes it occupies in the ROM.
+func (a *assembler) encode(s statement) ([]byte, error) {
+	switch s.mnemonic {
+	case "DB", "DW":
+		if len(s.operands) == 0 {
+			return nil, fmt.Errorf("%s requires a value", s.mnemonic)
+		}
+		var b []byte
+		for _, op := range s.operands {
+			if s.mnemonic == "DB" {
+				v, err := a.value(op, 0xFF)
+				if err != nil {
+					return nil, err
+				}
+				b = append(b, byte(v))
+				continue
+			}
+			v, err := a.value(op, 0xFFFF)
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, byte(v>>8), byte(v))
+		}
+		return b, nil
+	}
+	opcode, err := a.instruction(s.mnemonic, s.operands)
+	if err != nil {
+		return nil, err
+	}
+	return BuildROM(opcode), nil
+}
+
+// impliedOpcodes are the instructions that take no operands.
+var impliedOpcodes = map[string]uint16{
+	"CLS":  0x00E0,
+	"RET":  0x00EE,
+	"SCR":  0x00FB,
+	"SCL":  0x00FC,
+	"LOW":  0x00FE,
+	"HIGH": 0x00FF,
+}
+
+// logicOpcodes gives the final digit of the 8XYN instructions taking two registers.
+var logicOpcodes = map[string]uint16{
+	"OR":   0x1,
+	"AND":  0x2,
+	"XOR":  0x3,
+	"SUB":  0x5,
+	"SUBN": 0x7,
+}
+
+// instruction encodes a single opcode.
+func (a *assembler) instruction(mnemonic string, ops []string) (uint16, error) {
+	invalid := fmt.Errorf("invalid operands for %s", mnemonic)
+	var x, y uint16
+	var xok, yok bool
+	if len(ops) > 0 {
+		x, xok = register(ops[0])
+	}
+	if len(ops) > 1 {
+		y, yok = register(ops[1])
+	}
+
+	switch mnemonic {
+	case "CLS", "RET", "SCR", "SCL", "LOW", "HIGH":
+		if len(ops) != 0 {
+			return 0, invalid
+		}
+		return impliedOpcodes[mnemonic], nil
+	case "SCD", "PLANE":
+		if len(ops) != 1 {
+			return 0, invalid
+		}
+		if mnemonic == "PLANE" {
+			n, err := a.value(ops[0], 0x3)
+			return 0xF001 | n<<8, err
+		}
+		n, err := a.value(ops[0], 0xF)
+		return 0x00C0 | n, err
+	case "SYS", "CALL":
+		if len(ops) != 1 {
+			return 0, invalid
+		}
+		addr, err := a.value(ops[0], 0xFFF)
+		if mnemonic == "CALL" {
+			return 0x2000 | addr, err
+		}
+		return addr, err
+	case "JP":
+		switch {
+		case len(ops) == 1:
+			addr, err := a.value(ops[0], 0xFFF)
+			return 0x1000 | addr, err
+		case len(ops) == 2 && xok && x == 0:
+			addr, err := a.value(ops[1], 0xFFF)
+			return 0xB000 | addr, err
+		}
+	case "SE", "SNE":
+		if len(ops) != 2 || !xok {
+			return 0, invalid
+		}
+		if yok {
+			if mnemonic == "SE" {
+				return 0x5000 | x<<8 | y<<4, nil
+			}
+			return 0x9000 | x<<8 | y<<4, nil
+		}
+		nn, err := a.value(ops[1], 0xFF)
+		if mnemonic == "SE" {
+			return 0x3000 | x<<8 | nn, err
+		}
+		return 0x4000 | x<<8 | nn, err
+	case "LD":
+		if len(ops) != 2 {
+			return 0, invalid
+		}
+		return a.load(x, xok, y, yok, ops)
+	case "ADD":
+		switch {
+		case len(ops) != 2:
+		case strings.ToUpper(ops[0]) == "I" && yok:
+			return 0xF01E | y<<8, nil
+		case xok && yok:
+			return 0x8004 | x<<8 | y<<4, nil
+		case xok:
+			nn, err := a.value(ops[1], 0xFF)
+			return 0x7000 | x<<8 | nn, err
+		}
+	case "OR", "AND", "XOR", "SUB", "SUBN":
+		if len(ops) != 2 || !xok || !yok {
+			return 0, invalid
+		}
+		return 0x8000 | x<<8 | y<<4 | logicOpcodes[mnemonic], nil
+	case "SHR", "SHL":
+		// The source register defaults to the destination
+		switch {
+		case len(ops) == 1 && xok:
+			y = x
+		case len(ops) == 2 && xok && yok:
+		default:
+			return 0, invalid
+		}
+		if mnemonic == "SHR" {
+			return 0x8006 | x<<8 | y<<4, nil
+		}
+		return 0x800E | x<<8 | y<<4, nil
+	case "RND":
+		if len(ops) != 2 || !xok {
+			return 0, invalid
+		}
+		nn, err := a.value(ops[1], 0xFF)
+		return 0xC000 | x<<8 | nn, err
+	case "DRW":
+		if len(ops) != 3 || !xok || !yok {
+			return 0, invalid
+		}
+		n, err := a.value(ops[2], 0xF)
+		return 0xD000 | x<<8 | y<<4 | n, err
+	case "SKP", "SKNP":
+		if len(ops) != 1 || !xok {
+			return 0, invalid
+		}
+		if mnemonic == "SKP" {
+			return 0xE09E | x<<8, nil
+		}
+		return 0xE0A1 | x<<8, nil
+	default:
+		return 0, fmt.Errorf("unknown instruction %q", mnemonic)
+	}
+	return 0, invalid
+}
+
+// load encodes the many forms of the LD instruction.
+func (a *assembler) load(x uint16, xok bool, y uint16, yok bool, ops []string) (uint16, error) {
+	src, dst := strings.ToUpper(ops[1]), strings.ToUpper(ops[0])
+	if xok {
+		switch {
+		case yok:
+			return 0x8000 | x<<8 | y<<4, nil
+		case src == "DT":
+			return 0xF007 | x<<8, nil
+		case src == "K":
+			return 0xF00A | x<<8, nil
+		case src == "[I]":
+			return 0xF065 | x<<8, nil
+		case src == "R":
+			return 0xF085 | x<<8, nil
+		}
+		nn, err := a.value(ops[1], 0xFF)
+		return 0x6000 | x<<8 | nn, err
+	}
+	if dst == "I" {
+		addr, err := a.value(ops[1], 0xFFF)
+		return 0xA000 | addr, err
+	}
+	if yok {
+		switch dst {
+		case "DT":
+			return 0xF015 | y<<8, nil
+		case "ST":
+			return 0xF018 | y<<8, nil
+		case "F":
+			return 0xF029 | y<<8, nil
+		case "HF":
+			return 0xF030 | y<<8, nil
+		case "B":
+			return 0xF033 | y<<8, nil
+		case "[I]":
+			return 0xF055 | y<<8, nil
+		case "R":
+			return 0xF075 | y<<8, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid operands for LD")
+}
+
+// register parses a register operand such as V0 or vA.
+func register(op string) (uint16, bool) {
+	if len(op) != 2 || op[0] != 'V' && op[0] != 'v' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(op[1:], 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(n), true
+}
+
+// value parses a number or label operand no larger than max.
+func (a *assembler) value(op string, max uint16) (uint16, error) {
+	if addr, ok := a.labels[op]; ok {
+		if addr > max {
+			return 0, fmt.Errorf("label %q at 0x%X out of range", op, addr)
+		}
+		return addr, nil
+	}
+	if isLabel(op) {
+		return 0, fmt.Errorf("undefined label %q", op)
+	}
+	v, err := parseNumber(op)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", op)
+	}
+	if v > uint64(max) {
+		return 0, fmt.Errorf("value %s exceeds 0x%X", op, max)
+	}
+	return uint16(v), nil
+}
+
+// parseNumber parses a decimal number, or a hexadecimal number with a 0x
+// prefix. Other forms accepted by Go, such as octal, are rejected.
+func parseNumber(op string) (uint64, error) {
+	if len(op) > 2 && (op[:2] == "0x" || op[:2] == "0X") {
+		return strconv.ParseUint(op[2:], 16, 16)
+	}
+	return strconv.ParseUint(op, 10, 16)
+}
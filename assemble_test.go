@@ -0,0 +1,219 @@
+package chip8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAssembleRun(t *testing.T) {
+	src := `
+	; Count V0 up to 3, then store it as BCD
+		LD V0, 0
+	loop:
+		ADD V0, 1
+		SE V0, 3
+		JP loop
+		LD I, result
+		LD B, V0
+	done: JP done
+
+	result:
+		DB 0xFF, 0xFF, 0xFF
+	`
+	rom, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.RunCycles(100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 0, 3)
+	expectPC(t, cpu, 0x20C)
+	if !bytes.Equal(cpu.memory[0x20E:0x211], []byte{0, 0, 3}) {
+		t.Errorf("expected BCD of 3, got % X", cpu.memory[0x20E:0x211])
+	}
+}
+
+func TestAssembleOpcodes(t *testing.T) {
+	src := `
+		CLS
+		RET
+		SYS 0x123
+		JP 0x208
+		CALL start
+	start:
+		SE V1, 0x22
+		SNE V1, 0x22
+		SE V1, V2
+		LD V1, 0x22
+		ADD V1, 0x22
+		LD V1, V2
+		OR V1, V2
+		AND V1, V2
+		XOR V1, V2
+		ADD V1, V2
+		SUB V1, V2
+		SHR V1
+		SUBN V1, V2
+		SHL V1, V2
+		SNE V1, V2
+		LD I, 0x123
+		JP V0, 0x123
+		RND V1, 0x22
+		DRW V1, V2, 5
+		SKP V1
+		SKNP V1
+		LD V1, DT
+		LD V1, K
+		LD DT, V1
+		LD ST, V1
+		ADD I, V1
+		LD F, V1
+		LD HF, V1
+		LD B, V1
+		LD [I], V1
+		LD V1, [I]
+		LD R, V1
+		LD V1, R
+		SCD 4
+		SCR
+		SCL
+		LOW
+		HIGH
+		PLANE 2
+		DW 0xBEEF
+	`
+	expected := BuildROM(
+		0x00E0, 0x00EE, 0x0123, 0x1208, 0x220A,
+		0x3122, 0x4122, 0x5120, 0x6122, 0x7122,
+		0x8120, 0x8121, 0x8122, 0x8123, 0x8124, 0x8125, 0x8116, 0x8127, 0x812E,
+		0x9120, 0xA123, 0xB123, 0xC122, 0xD125, 0xE19E, 0xE1A1,
+		0xF107, 0xF10A, 0xF115, 0xF118, 0xF11E, 0xF129, 0xF130, 0xF133,
+		0xF155, 0xF165, 0xF175, 0xF185,
+		0x00C4, 0x00FB, 0x00FC, 0x00FE, 0x00FF, 0xF201, 0xBEEF,
+	)
+	rom, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(rom, expected) {
+		t.Errorf("expected\n% X\ngot\n% X", expected, rom)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	var tests = []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "unknown instruction",
+			src:      "CLS\nFOO V1",
+			expected: `line 2: unknown instruction "FOO"`,
+		},
+		{
+			name:     "undefined label",
+			src:      "CLS\n\nJP nowhere",
+			expected: `line 3: undefined label "nowhere"`,
+		},
+		{
+			name:     "duplicate label",
+			src:      "a: CLS\na: CLS",
+			expected: `line 2: duplicate label "a"`,
+		},
+		{
+			name:     "register label",
+			src:      "V1: CLS",
+			expected: `line 1: invalid label "V1"`,
+		},
+		{
+			name:     "value out of range",
+			src:      "LD V1, 0x100",
+			expected: "line 1: value 0x100 exceeds 0xFF",
+		},
+		{
+			name:     "binary value",
+			src:      "LD V1, 0b1",
+			expected: `line 1: invalid value "0b1"`,
+		},
+		{
+			name:     "octal value",
+			src:      "LD V1, 0o7",
+			expected: `line 1: invalid value "0o7"`,
+		},
+		{
+			name:     "underscore in value",
+			src:      "LD V1, 1_0",
+			expected: `line 1: invalid value "1_0"`,
+		},
+		{
+			name:     "plane out of range",
+			src:      "PLANE 4",
+			expected: "line 1: value 4 exceeds 0x3",
+		},
+		{
+			name:     "invalid operands",
+			src:      "DRW V1, V2",
+			expected: "line 1: invalid operands for DRW",
+		},
+		{
+			name:     "invalid load",
+			src:      "LD DT, 5",
+			expected: "line 1: invalid operands for LD",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Assemble(test.src)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if err.Error() != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestAssembleNumbers(t *testing.T) {
+	// A leading zero does not make a number octal
+	rom, err := Assemble("LD V1, 010\nLD V2, 0x1F\nLD V3, 0XFF")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := BuildROM(0x610A, 0x621F, 0x63FF); !bytes.Equal(rom, expected) {
+		t.Errorf("expected % X, got % X", expected, rom)
+	}
+}
+
+func TestAssembleDisassemble(t *testing.T) {
+	src := strings.Join([]string{
+		"LD V0, 5",
+		"LD I, 0x300",
+		"DRW V0, V0, 5",
+		"JP 0x206",
+	}, "\n")
+	rom, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	instructions, err := Disassemble(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"0x6XNN", "0xANNN", "0xDXYN", "0x1NNN"}
+	if len(instructions) != len(expected) {
+		t.Fatalf("expected %d instructions, got %d", len(expected), len(instructions))
+	}
+	for i, in := range instructions {
+		if in.OpcodeType != expected[i] {
+			t.Errorf("instruction %d: expected %s, got %s", i, expected[i], in.OpcodeType)
+		}
+	}
+}
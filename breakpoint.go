@@ -0,0 +1,57 @@
+package chip8
+
+import "errors"
+
+// AddBreakpoint stops execution when the program counter reaches addr.
+// EmulateCycle returns an error wrapping ErrBreakpoint before executing
+// the opcode at addr, leaving the machine unchanged. Calling EmulateCycle
+// again resumes execution from the breakpoint.
+func (c *Chip8) AddBreakpoint(addr uint16) {
+	if c.breakpoints == nil {
+		c.breakpoints = make(map[uint16]struct{})
+	}
+	c.breakpoints[addr] = struct{}{}
+}
+
+// RemoveBreakpoint removes any breakpoint at addr.
+func (c *Chip8) RemoveBreakpoint(addr uint16) {
+	delete(c.breakpoints, addr)
+}
+
+// RunUntilBreak emulates cycles until execution stops at a breakpoint,
+// returning the Result of the last cycle executed before it.
+// Execution also stops at a Result with Halted, Paused or Waiting set, which
+// is returned, or if a cycle fails. A wait can only end by pressing a key or
+// advancing the clock, which cannot happen while RunUntilBreak is running.
+// Without a breakpoint in its path, a program that never halts or waits will
+// run forever.
+func (c *Chip8) RunUntilBreak() (Result, error) {
+	var last Result
+	for {
+		result, err := c.EmulateCycle()
+		if errors.Is(err, ErrBreakpoint) {
+			return last, nil
+		}
+		if err != nil || result.Halted || result.Paused || result.Waiting {
+			return result, err
+		}
+		last = result
+	}
+}
+
+// checkBreakpoint returns ErrBreakpoint iff execution should stop at pc.
+// A breakpoint that has stopped execution is skipped until the program
+// counter leaves it, so that execution may resume even if the opcode there
+// does not advance, such as FX0A waiting for a key.
+func (c *Chip8) checkBreakpoint(pc uint16) error {
+	if c.breakpointHit && c.breakpointPC == pc {
+		return nil
+	}
+	c.breakpointHit = false
+	if _, ok := c.breakpoints[pc]; !ok {
+		return nil
+	}
+	c.breakpointHit = true
+	c.breakpointPC = pc
+	return ErrBreakpoint
+}
@@ -0,0 +1,193 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBreakpoint(t *testing.T) {
+	// V0 = 1; V1 = 2; V2 = 3
+	rom := BuildROM(0x6001, 0x6102, 0x6203)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.AddBreakpoint(0x202)
+
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = cpu.EmulateCycle()
+	if !errors.Is(err, ErrBreakpoint) {
+		t.Fatalf("expected breakpoint error, got %v", err)
+	}
+	var emuErr *Error
+	if !errors.As(err, &emuErr) || emuErr.PC != 0x202 || emuErr.Opcode != 0x6102 {
+		t.Errorf("expected error at 0x202 for opcode 0x6102, got %v", err)
+	}
+	expectPC(t, cpu, 0x202)
+	expectRegister(t, cpu, 1, 0)
+
+	// Resuming executes the opcode at the breakpoint
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error on resume: %v", err)
+	}
+	expectPC(t, cpu, 0x204)
+	expectRegister(t, cpu, 1, 2)
+
+	// Removed breakpoints no longer stop execution
+	cpu.Reset()
+	cpu.RemoveBreakpoint(0x202)
+	for i := 0; i < 3; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error on cycle %d: %v", i, err)
+		}
+	}
+	expectPC(t, cpu, 0x206)
+}
+
+func TestBreakpointOnWaitingOpcode(t *testing.T) {
+	// V0 = get_key(); goto 0x202
+	rom := BuildROM(0xF00A, 0x1202)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.AddBreakpoint(0x200)
+
+	if _, err := cpu.EmulateCycle(); !errors.Is(err, ErrBreakpoint) {
+		t.Fatalf("expected breakpoint error, got %v", err)
+	}
+	// The breakpoint is not hit again while waiting at it
+	for i := 0; i < 4; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error on cycle %d: %v", i, err)
+		}
+		expectPC(t, cpu, 0x200)
+	}
+
+	cpu.SetKeyState(0x7, true)
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectPC(t, cpu, 0x202)
+	expectRegister(t, cpu, 0, 0x7)
+}
+
+func TestConsecutiveBreakpoints(t *testing.T) {
+	// V0 = 1; V1 = 2
+	cpu, err := New(bytes.NewReader(BuildROM(0x6001, 0x6102)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.AddBreakpoint(0x200)
+	cpu.AddBreakpoint(0x202)
+	for _, expected := range []error{ErrBreakpoint, nil, ErrBreakpoint, nil} {
+		if _, err := cpu.EmulateCycle(); !errors.Is(err, expected) {
+			t.Fatalf("expected %v, got %v", expected, err)
+		}
+	}
+	expectPC(t, cpu, 0x204)
+}
+
+func TestBreakpointInLoop(t *testing.T) {
+	// V0 += 1; goto 0x200
+	rom := BuildROM(0x7001, 0x1200)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.AddBreakpoint(0x200)
+
+	// Each pass around the loop stops at the breakpoint once
+	for pass := 1; pass <= 3; pass++ {
+		if _, err := cpu.EmulateCycle(); !errors.Is(err, ErrBreakpoint) {
+			t.Fatalf("expected breakpoint on pass %d, got %v", pass, err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error on pass %d: %v", pass, err)
+			}
+		}
+		expectRegister(t, cpu, 0, byte(pass))
+	}
+}
+
+func TestRunUntilBreak(t *testing.T) {
+	// V0 += 1; V1 += 2; goto 0x200
+	rom := BuildROM(0x7001, 0x7102, 0x1200)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.AddBreakpoint(0x202)
+
+	for pass := 1; pass <= 3; pass++ {
+		r, err := cpu.RunUntilBreak()
+		if err != nil {
+			t.Fatalf("unexpected error on pass %d: %v", pass, err)
+		}
+		expectOpcodeType(t, r, "0x7XNN")
+		if r.Opcode != 0x7001 {
+			t.Errorf("expected last opcode 0x7001, got 0x%04X", r.Opcode)
+		}
+		expectPC(t, cpu, 0x202)
+		expectRegister(t, cpu, 0, byte(pass))
+		expectRegister(t, cpu, 1, byte(2*(pass-1)))
+	}
+}
+
+func TestRunUntilBreakHalts(t *testing.T) {
+	// V0 = 1; goto 0x202
+	cpu, err := New(bytes.NewReader(BuildROM(0x6001, 0x1202)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := cpu.RunUntilBreak()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Halted {
+		t.Errorf("expected to stop at the halt, got %+v", r)
+	}
+	expectRegister(t, cpu, 0, 1)
+}
+
+func TestRunUntilBreakWaits(t *testing.T) {
+	t.Run("key", func(t *testing.T) {
+		// V0 = get_key(); goto 0x202
+		cpu, err := New(bytes.NewReader(BuildROM(0xF00A, 0x1202)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cpu.AddBreakpoint(0x202)
+		r, err := cpu.RunUntilBreak()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !r.Waiting {
+			t.Errorf("expected to stop while waiting for a key, got %+v", r)
+		}
+		expectPC(t, cpu, 0x200)
+	})
+
+	t.Run("display", func(t *testing.T) {
+		// I = 0x50; draw(V0,V0,5); goto 0x204
+		rom := BuildROM(0xA050, 0xD005, 0x1204)
+		cpu, err := New(bytes.NewReader(rom), WithQuirks(Quirks{DisplayWait: true}), WithClock(&ManualClock{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cpu.AddBreakpoint(0x204)
+		r, err := cpu.RunUntilBreak()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !r.Waiting {
+			t.Errorf("expected to stop while waiting for the display, got %+v", r)
+		}
+		expectPC(t, cpu, 0x202)
+	})
+}
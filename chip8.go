@@ -4,14 +4,20 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
+	"net/http"
 	"time"
 )
 
+// Display resolutions
+const (
+	lowResWidth, lowResHeight   = 64, 32
+	highResWidth, highResHeight = 128, 64
+)
+
 // Chip8 emulates a CHIP-8 machine.
 // An initialized instance can be created with New()
 type Chip8 struct {
-	opcode uint16
-
 	memory [4096]byte
 
 	// The Chip 8 has 15 8-bit general purpose registers named V0,V1 up to VE.
@@ -19,6 +25,10 @@ type Chip8 struct {
 	// Eight bits is one byte so we can use an unsigned char for this purpose
 	V [16]byte
 
+	// SUPER-CHIP can save registers to the HP48's RPL user flags with FX75,
+	// and restore them with FX85. These survive a Reset.
+	rpl [8]byte
+
 	// There is an Index register I and a program counter (pc) which can have a value from 0x000 to 0xFFF
 	I  uint16
 	pc uint16
@@ -26,6 +36,7 @@ type Chip8 struct {
 	// The systems memory map:
 	// 0x000-0x1FF - Chip 8 interpreter (contains font set in emu)
 	// 0x050-0x0A0 - Used for the built in 4x5 pixel font set (0-F)
+	// 0x0A0-0x103 - Used for the SUPER-CHIP 8x10 pixel font set (0-9)
 	// 0x200-0xFFF - Program ROM and work RAM
 
 	// The graphics system: The chip 8 has one instruction that draws sprite to the screen.
@@ -33,7 +44,13 @@ type Chip8 struct {
 	// This is used for collision detection.
 	// The graphics of the Chip 8 are black and white and the screen has a total of 2048 pixels (64 x 32).
 	// This can easily be implemented using an array that hold the pixel state (1 or 0):
-	gfx [64 * 32]byte
+	gfx []byte
+	// SUPER-CHIP adds a high resolution mode of 128 x 64, so the dimensions of gfx may change.
+	width, height int
+	// XO-CHIP adds a second bit-plane, allowing four colors.
+	// planeMask selects the planes that are drawn to, bit 0 for gfx and bit 1 for gfx2.
+	gfx2      []byte
+	planeMask byte
 
 	// Interupts and hardware registers.
 	// The Chip 8 has none, but there are two timer registers that count at 60 Hz.
@@ -52,14 +69,124 @@ type Chip8 struct {
 	// Finally, the Chip 8 has a HEX based keypad (0x0-0xF), you can use an array to store the current state of the key.
 	key [16]byte
 
+	// Set while FX0A is waiting for a key press to store in keyRegister
+	waitingForKey bool
+	keyRegister   uint16
+	// With the KeyRelease quirk, the key pressed during FX0A that must be released
+	waitKey        byte
+	waitKeyPressed bool
+
+	// Behaviors that vary between interpreters
+	quirks Quirks
+
+	// Keys pressed by SetDirection
+	directionKeys DirectionKeys
+
+	// 4x5 font used by FX29, and where it is loaded in memory
+	font        []byte
+	fontAddress uint16
+
 	// True iff the screen must be drawn
 	drawFlag bool
-
-	timerClock *time.Ticker
+	// Set at each timer tick, and cleared by DXYN with the DisplayWait quirk
+	vblank bool
+
+	// Pixels shown when the display is cleared, repeated to fill the display
+	clearPattern []byte
+	// Area of the display drawn to since the frame began, and the contents
+	// of both planes when it began
+	dirty      Region
+	frameStart []byte
+
+	// Source of time for the 60Hz timers, and the time of the last timer update
+	clock    Clock
+	lastTick time.Time
+	// Whether the timers are only counted down by calls to TickTimers
+	manualTimers bool
+	// Whether execution and the timers have been stopped by Pause
+	paused bool
+
+	// Source of random numbers for CXNN, created when first needed
+	random *rand.Rand
+
+	// Intended number of cycles per second
+	clockSpeed int
 
 	opcodes map[uint16]opcodeHandler
 
-	beepOut chan struct{}
+	beepOut chan BeepEvent
+
+	// Number of bytes in the loaded ROM, and a copy of it for Reset
+	romSize int
+	rom     []byte
+
+	// Where the ROM is loaded in memory, and where execution starts if not
+	// at the start of the ROM
+	loadAddress uint16
+	entryPoint  uint16
+
+	// Number of cycles executed
+	cycles uint64
+
+	// Activity counts, and cycles executed since the last timer tick
+	metrics     MetricsSummary
+	frameCycles uint64
+
+	// Consecutive cycles without the program counter advancing, and the
+	// number at which the watchdog will stop execution
+	stalledCycles int
+	watchdogLimit int
+
+	// Addresses at which to stop execution, and whether execution has
+	// stopped at breakpointPC without the program counter leaving it since
+	breakpoints   map[uint16]struct{}
+	breakpointHit bool
+	breakpointPC  uint16
+
+	// Addresses at which to report writes, and those written during the
+	// current cycle
+	watchpoints    map[uint16]struct{}
+	watchpointHits []uint16
+
+	// Ring of states before recent cycles, for StepBack
+	history      []snapshot
+	historyDepth int
+	historyNext  int
+	historyLen   int
+
+	// Optional measurement of sprite flicker
+	flicker *flickerDetector
+
+	// True once Close has been called
+	closed bool
+
+	// What to do when execution runs past the end of the ROM, and whether
+	// the machine has halted as a result
+	endOfProgram EndOfProgramBehavior
+	halted       bool
+
+	// What to do when a program calls machine code, and the hook to call
+	machineCode   MachineCodeBehavior
+	onMachineCode func(addr uint16) error
+
+	// Hooks for observing execution
+	onDelayTimerRead func(value byte)
+	onFlagChange     func(old, new byte, reason string)
+
+	// Optional tracing of executed opcodes, limited to the opcode types in traceFilter if not nil
+	tracer      func(Result)
+	traceFilter map[string]struct{}
+	// Describe cycles in Results even when not tracing
+	fullResults bool
+	// Pseudocode previously formatted for each opcode, the configuration it
+	// was formatted under, and whether the current opcode's pseudocode is
+	// being taken from the cache
+	pseudoCache    map[uint16]string
+	pseudoCacheKey pseudoCacheKey
+	pseudoCached   bool
+
+	// Client used to fetch ROMs in NewFromURL
+	httpClient *http.Client
 }
 
 // Result records the actions performed when handling an opcode.
@@ -67,7 +194,23 @@ type Chip8 struct {
 type Result struct {
 	Opcode     uint16
 	OpcodeType string
-	Pseudo     string
+	// Pseudo, Before and After are only populated while tracing, or when
+	// configured with WithFullResults.
+	Pseudo string
+
+	// Halted is set if no opcode was executed because the machine has halted,
+	// or if the opcode was a jump to its own address, which the program can
+	// never leave. A front-end may stop calling EmulateCycle once it is set.
+	Halted bool
+	// Paused is set if no opcode was executed because the machine is paused
+	Paused bool
+	// Waiting is set if the program is waiting for a key press (FX0A), or
+	// for the display to refresh before drawing with the DisplayWait quirk.
+	// The program counter does not advance until the wait is over.
+	Waiting bool
+
+	// Watched memory addresses written by the opcode, in the order written
+	WatchpointHits []uint16
 
 	Before ResultState
 	After  ResultState
@@ -84,12 +227,12 @@ type ResultState struct {
 // Empty registers, stack and display, zeroed timers and
 // memory populated with font data and the contents of a ROM
 // provided in an io.Reader.
+// Additional behavior may be configured by providing Options.
 //
 // The Chip8 instance returned will be ready to start processing
 // opcodes with calls to ExecuteCycle.
-func New(rom io.Reader) (*Chip8, error) {
-	c := &Chip8{}
-	c.initialize()
+func New(rom io.Reader, opts ...Option) (*Chip8, error) {
+	c := newChip8(opts)
 
 	err := c.loadROM(rom)
 	if err != nil {
@@ -99,18 +242,50 @@ func New(rom io.Reader) (*Chip8, error) {
 	return c, nil
 }
 
+// newChip8 creates an initialized machine with the provided options applied
+// and no ROM loaded.
+func newChip8(opts []Option) *Chip8 {
+	c := &Chip8{}
+	c.initialize()
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.pc = c.startAddress()
+	return c
+}
+
 func (c *Chip8) initialize() {
 	// Set up opcode mapping
 	c.registerOpcodeHandlers()
 
+	c.loadAddress = defaultLoadAddress
+	c.font = chip8Fontset
+	c.fontAddress = defaultFontAddress
+	c.directionKeys = defaultDirectionKeys
+	c.resetState()
+
+	// Set up output for beeps
+	c.beepOut = make(chan BeepEvent, beepBufferSize)
+
+	// Count timer ticks from now
+	c.clock = realClock{}
+	c.lastTick = c.clock.Now()
+	c.clockSpeed = defaultClockSpeed
+
+	c.httpClient = &http.Client{Timeout: defaultFetchTimeout}
+}
+
+// resetState clears registers, memory, display, timers and keys.
+func (c *Chip8) resetState() {
 	// Initialize registers and memory once
-	c.pc = 0x200 // Program counter starts at 0x200
-	c.opcode = 0 // Reset current opcode
-	c.I = 0      // Reset index register
-	c.sp = 0     // Reset stack pointer
+	c.pc = c.startAddress() // Program counter starts at 0x200 by default
+	c.I = 0                 // Reset index register
+	c.sp = 0                // Reset stack pointer
 
-	// Clear display
-	c.gfx = [64 * 32]byte{}
+	// Clear display, which starts out blank so needs no redraw
+	c.planeMask = 0x1
+	c.setResolution(lowResWidth, lowResHeight)
+	c.drawFlag = false
 	// Clear stack
 	c.stack = [16]uint16{}
 	// Clear registers V0-VF
@@ -119,18 +294,34 @@ func (c *Chip8) initialize() {
 	c.memory = [4096]byte{}
 
 	// Load fontset
-	for i := 0; i < len(chip8Fontset); i++ {
-		c.memory[i] = chip8Fontset[i]
-	}
+	c.loadFont()
 	// Reset timers
 	c.delayTimer = 0
 	c.soundTimer = 0
 
-	// Set up output for beeps
-	c.beepOut = make(chan struct{})
+	// Release all keys
+	c.key = [16]byte{}
+	c.waitingForKey = false
+	c.waitKeyPressed = false
 
-	// Create a ticker at 60Hz
-	c.timerClock = time.NewTicker(time.Second / 60)
+	c.vblank = false
+	c.halted = false
+	c.stalledCycles = 0
+	c.breakpointHit = false
+
+	// Forget history from before the reset
+	c.clearHistory()
+}
+
+// Reset returns the machine to its starting condition, with the ROM it was
+// created with reloaded into memory. Options provided when creating the
+// machine still apply, and metrics continue to accumulate.
+// The draw flag is cleared along with the display, so a front-end showing the
+// previous display should clear it too.
+func (c *Chip8) Reset() {
+	c.resetState()
+	copy(c.memory[c.loadAddress:], c.rom)
+	c.lastTick = c.clock.Now()
 }
 
 // loadROM loads a ROM into memory from an io.Reader
@@ -139,94 +330,406 @@ func (c *Chip8) loadROM(rom io.Reader) error {
 	if err != nil {
 		return err
 	}
+	return c.Load(bytes)
+}
 
-	for i := 0; i < len(bytes); i++ {
-		c.memory[i+512] = bytes[i]
+// Load replaces the ROM with the provided bytes and resets the machine, ready
+// to start executing it.
+// An error wrapping ErrROMTooLarge is returned if the ROM does not fit into
+// memory, in which case the machine is unchanged.
+func (c *Chip8) Load(rom []byte) error {
+	if len(rom) > c.romCapacity() {
+		return fmt.Errorf("%w: %d bytes, max %d", ErrROMTooLarge, len(rom), c.romCapacity())
 	}
-
+	c.romSize = len(rom)
+	c.rom = append([]byte(nil), rom...)
+	c.Reset()
 	return nil
 }
 
-// SetKeyDown will mark the specified key as down.
-// Once read by the current program, the key state will be reset to up.
-func (c *Chip8) SetKeyDown(index byte) {
+// SetKeyState sets the physical state of the specified key. A key stays
+// down until it is released, so a program polling it with EX9E or EXA1 sees
+// it held across any number of checks.
+// If the program is waiting for a key press (FX0A), pressing a key stores it
+// in the waiting register and execution continues with the next cycle. With
+// the KeyRelease quirk, the wait completes when the key is released instead.
+// Indices beyond 0xF are ignored.
+func (c *Chip8) SetKeyState(index byte, down bool) {
+	if int(index) >= len(c.key) {
+		return
+	}
+	if !down {
+		c.key[index] = 0
+		if c.waitingForKey && c.waitKeyPressed && c.waitKey == index {
+			c.completeKeyWait(index)
+		}
+		return
+	}
+	if c.waitingForKey && c.quirks.KeyRelease {
+		if !c.waitKeyPressed {
+			c.waitKey = index
+			c.waitKeyPressed = true
+		}
+	} else if c.waitingForKey {
+		c.completeKeyWait(index)
+	}
 	c.key[index] = 1
 }
 
+// SetKeyDown will mark the specified key as held down until SetKeyUp is called.
+//
+// Deprecated: Use SetKeyState(index, true).
+func (c *Chip8) SetKeyDown(index byte) {
+	c.SetKeyState(index, true)
+}
+
+// SetKeyUp will mark the specified key as up.
+//
+// Deprecated: Use SetKeyState(index, false).
+func (c *Chip8) SetKeyUp(index byte) {
+	c.SetKeyState(index, false)
+}
+
+// GetKeys returns the current state of the keypad, true for each key held down.
+func (c *Chip8) GetKeys() [16]bool {
+	var keys [16]bool
+	for i, k := range c.key {
+		keys[i] = k != 0
+	}
+	return keys
+}
+
+// completeKeyWait stores a key for FX0A and continues execution.
+func (c *Chip8) completeKeyWait(index byte) {
+	c.V[c.keyRegister] = index
+	c.waitingForKey = false
+	c.waitKeyPressed = false
+	c.pc += 2
+}
+
 // GetGraphics returns the current state of the graphics memory.
 // Graphics are 64x32. Each pixel is represented as a byte, 0 = off,
 // !0 = on.
+// With XO-CHIP bit-planes, each pixel is a color index from 0-3,
+// with bit 0 from the first plane and bit 1 from the second.
+// In high resolution mode, each returned pixel is on if any of the
+// 2x2 block of pixels it covers is on. Use GetGraphicsBuffer to
+// obtain the full resolution display.
 func (c *Chip8) GetGraphics() [64 * 32]byte {
-	return c.gfx
+	var graphics [64 * 32]byte
+	buffer := c.GetGraphicsBuffer()
+	if c.width == lowResWidth {
+		copy(graphics[:], buffer)
+		return graphics
+	}
+	scale := c.width / lowResWidth
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			graphics[(y/scale)*lowResWidth+x/scale] |= buffer[y*c.width+x]
+		}
+	}
+	return graphics
+}
+
+// GetGraphicsBuffer returns a copy of the graphics memory at the active
+// resolution, one byte per pixel in rows from the top left.
+// Pixels are color indexes, as for GetGraphics.
+func (c *Chip8) GetGraphicsBuffer() []byte {
+	buffer := make([]byte, len(c.gfx))
+	for i := range buffer {
+		buffer[i] = c.gfx[i] | c.gfx2[i]<<1
+	}
+	return buffer
+}
+
+// GetGraphicsPlanes returns a copy of each XO-CHIP bit-plane at the active
+// resolution. Classic CHIP-8 programs only draw to the first plane.
+func (c *Chip8) GetGraphicsPlanes() [][]byte {
+	return [][]byte{
+		append([]byte(nil), c.gfx...),
+		append([]byte(nil), c.gfx2...),
+	}
+}
+
+// Resolution returns the width and height of the display in pixels.
+// This will be 64x32, or 128x64 while SUPER-CHIP high resolution mode is active.
+func (c *Chip8) Resolution() (w, h int) {
+	return c.width, c.height
+}
+
+// StackDepth returns the number of subroutine calls that have not yet returned.
+func (c *Chip8) StackDepth() int {
+	return int(c.sp)
+}
+
+// setResolution resizes the display, clearing it.
+func (c *Chip8) setResolution(w, h int) {
+	c.width, c.height = w, h
+	c.gfx = make([]byte, w*h)
+	c.gfx2 = make([]byte, w*h)
+	if c.flicker != nil {
+		c.flicker.resize(len(c.gfx))
+	}
+	c.clearPlanes(0x3)
+	c.drawFlag = true
+}
+
+// SetClearPattern sets the pixels that the display is filled with when cleared.
+// The pattern is repeated as many times as needed to fill the graphics memory,
+// so a pattern of []byte{1, 0} will produce alternating columns.
+// The pattern applies to the first bit-plane, the second is always cleared to off.
+// Any non-zero byte in the pattern is a pixel that is on, stored as 1 so that
+// drawing over it collides as it would with a drawn pixel.
+// An empty pattern restores the default of all pixels off.
+func (c *Chip8) SetClearPattern(pattern []byte) {
+	c.clearPattern = make([]byte, len(pattern))
+	for i, p := range pattern {
+		if p != 0 {
+			c.clearPattern[i] = 1
+		}
+	}
 }
 
-// Beep returns a channel that outputs a value whenever a beep is to be played.
-func (c *Chip8) Beep() <-chan struct{} {
+// clearPlanes resets the bit-planes selected by mask to the clear pattern.
+func (c *Chip8) clearPlanes(mask byte) {
+	c.markAllDirty()
+	if mask&0x2 != 0 {
+		for i := range c.gfx2 {
+			c.gfx2[i] = 0
+		}
+	}
+	if mask&0x1 == 0 {
+		return
+	}
+	for i := range c.gfx {
+		if len(c.clearPattern) == 0 {
+			c.gfx[i] = 0
+			continue
+		}
+		c.gfx[i] = c.clearPattern[i%len(c.clearPattern)]
+	}
+}
+
+// selectedPlanes returns the bit-planes selected for drawing.
+func (c *Chip8) selectedPlanes() [][]byte {
+	var planes [][]byte
+	if c.planeMask&0x1 != 0 {
+		planes = append(planes, c.gfx)
+	}
+	if c.planeMask&0x2 != 0 {
+		planes = append(planes, c.gfx2)
+	}
+	return planes
+}
+
+// Beep returns a channel that outputs an event whenever a beep is to be played.
+// A beep starts when the sound timer is set to a nonzero value, and lasts for
+// as long as the timer counts down. Events are buffered, but dropped rather
+// than blocking emulation if they are not read.
+func (c *Chip8) Beep() <-chan BeepEvent {
 	return c.beepOut
 }
 
+// Close stops the machine and closes the Beep channel.
+// Any further calls to EmulateCycle will return ErrClosed.
+func (c *Chip8) Close() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.beepOut)
+}
+
 // EmulateCycle will execute a single clock cycle on this CHIP-8 cpu.
 // Every cycle will return a Result containing information about the state before
 // and after this cycle.
 // Result will be populated regardless of whether or not an error is returned.
+//
+// Errors encountered while executing the cycle are returned as an *Error
+// wrapping one of the error classes such as ErrUnknownOpcode.
 func (c *Chip8) EmulateCycle() (Result, error) {
-	// Fetch Opcode
-	opcode := uint16(c.memory[c.pc])<<8 | uint16(c.memory[c.pc+1])
+	pc := c.pc
+	if c.closed {
+		state := c.currentState()
+		return Result{
+			Before: state,
+			After:  state,
+		}, c.wrapError(ErrClosed, pc, 0)
+	}
 
-	before := c.currentState()
+	if c.paused {
+		state := c.currentState()
+		return Result{
+			Paused: true,
+			Before: state,
+			After:  state,
+		}, nil
+	}
 
-	// Decode and Handle Opcode
-	handler, ok := c.opcodes[opcode&0xF000]
-	if !ok {
+	// The whole opcode must be within memory
+	if int(pc)+1 >= len(c.memory) {
+		state := c.currentState()
 		return Result{
-			Opcode: opcode,
-			Before: before,
-			After:  before,
-		}, fmt.Errorf("unknown opcode: 0x%X", c.opcode)
+			Before: state,
+			After:  state,
+		}, c.wrapError(fmt.Errorf("%w: 0x%X", ErrPCOutOfRange, pc), pc, 0)
 	}
 
-	result, err := handler(opcode)
+	if err := c.checkBreakpoint(pc); err != nil {
+		state := c.currentState()
+		return Result{
+			Before: state,
+			After:  state,
+		}, c.wrapError(err, pc, c.opcodeAt(pc))
+	}
+
+	halted, err := c.checkEndOfProgram()
+	if halted || err != nil {
+		state := c.currentState()
+		result := Result{
+			Halted: halted,
+			Before: state,
+			After:  state,
+		}
+		if err != nil {
+			return result, c.wrapError(err, pc, c.opcodeAt(pc))
+		}
+		return result, nil
+	}
+
+	c.recordHistory()
+
+	describe := c.describing()
+	var before ResultState
+	if describe {
+		before = c.currentState()
+	}
+
+	// Fetch Opcode
+	opcode := c.opcodeAt(pc)
+
+	result, err := c.execute(opcode)
 	result.Opcode = opcode
-	result.Before = before
-	result.After = c.currentState()
+	// A jump to itself can never be left, so the program has finished
+	result.Halted = err == nil && opcode == 0x1000|pc
+	result.Waiting = result.Waiting || c.waitingForKey
+	result.WatchpointHits = c.watchpointHits
+	c.watchpointHits = nil
+	if describe {
+		result.Before = before
+		result.After = c.currentState()
+	}
+	c.trace(result)
+	if err == nil {
+		err = c.checkWatchdog(pc, opcode)
+	}
 	if err != nil {
-		return result, err
+		return result, c.wrapError(err, pc, opcode)
+	}
+	c.cycles++
+	c.frameCycles++
+
+	if !c.manualTimers {
+		c.tickTimers()
 	}
 
-	select {
-	case <-c.timerClock.C:
-		// Update timers
-		if c.delayTimer > 0 {
-			c.delayTimer--
+	return result, nil
+}
+
+// Step emulates up to n cycles, returning the Result of each.
+// If a cycle fails, the Results of the preceding cycles are returned with
+// the error.
+func (c *Chip8) Step(n int) ([]Result, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	results := make([]Result, 0, n)
+	for i := 0; i < n; i++ {
+		result, err := c.EmulateCycle()
+		if err != nil {
+			return results, err
 		}
+		results = append(results, result)
+	}
+	return results, nil
+}
 
-		if c.soundTimer > 0 {
-			if c.soundTimer == 1 {
-				// Don't block if the beep routine isn't ready
-				select {
-				case c.beepOut <- struct{}{}:
-				default:
-				}
-			}
-			c.soundTimer--
+// RunCycles emulates up to n cycles for running a program to completion
+// without a display, such as a test ROM in CI.
+// Execution stops early once a Result has Halted set, such as at a jump to
+// its own address (1NNN), the conventional way for a program to end.
+// It also stops if the machine is paused.
+// The Results collected so far are returned, along with any error.
+func (c *Chip8) RunCycles(n int) ([]Result, error) {
+	var results []Result
+	for i := 0; i < n; i++ {
+		result, err := c.EmulateCycle()
+		if err != nil {
+			return results, err
+		}
+		if result.Paused {
+			break
+		}
+		results = append(results, result)
+		if result.Halted {
+			break
 		}
-	default:
-		// Skip the timers
 	}
+	return results, nil
+}
 
-	return result, nil
+// execute decodes and handles an opcode.
+// No work is done while waiting for a key press.
+func (c *Chip8) execute(opcode uint16) (Result, error) {
+	if c.waitingForKey {
+		result := c.describe(0xF00A | c.keyRegister<<8)
+		result.OpcodeType = "0xFX0A (waiting)"
+		return result, nil
+	}
+
+	handler, ok := c.opcodes[opcode&0xF000]
+	if !ok {
+		return Result{}, ErrUnknownOpcode
+	}
+	if c.pseudoCache != nil && c.describing() {
+		return c.executeCached(handler, opcode)
+	}
+	return handler(opcode)
+}
+
+// updateTimers counts down the delay and sound timers by one 60Hz tick.
+func (c *Chip8) updateTimers() {
+	c.countFrame()
+	c.vblank = true
+
+	if c.delayTimer > 0 {
+		c.delayTimer--
+	}
+
+	if c.soundTimer > 0 {
+		c.soundTimer--
+	}
 }
 
 // DrawFlag returns the current state of the draw flag.
 // Iff true, the screen will need to be re-drawn using the values in
 // GetGraphics.
-// Reading the flag will reset it to false.
+// Reading the flag will reset it to false, and clear the dirty region
+// returned by EndFrame.
 func (c *Chip8) DrawFlag() bool {
 	flag := c.drawFlag
 	c.drawFlag = false
+	c.dirty = Region{}
 	return flag
 }
 
+// SetDrawFlag sets the draw flag, so the next call to DrawFlag returns value.
+// Setting it to true forces a redraw, while setting it to false suppresses
+// the redraw for any changes to the display since DrawFlag was last called.
+func (c *Chip8) SetDrawFlag(value bool) {
+	c.drawFlag = value
+}
+
 func (c *Chip8) currentState() ResultState {
 	return ResultState{
 		PC: c.pc,
@@ -0,0 +1,536 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSoundTimerCountdown(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[0] = 5
+	if _, err := cpu.opcode0xF000(0xF018); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A single beep is output when the timer starts, lasting until it stops
+	select {
+	case beep := <-cpu.Beep():
+		if expected := 5 * timerPeriod; beep.Duration != expected {
+			t.Errorf("expected beep to last %v, got %v", expected, beep.Duration)
+		}
+	default:
+		t.Fatal("expected a beep")
+	}
+
+	for i := 0; i < 10; i++ {
+		cpu.updateTimers()
+	}
+	if cpu.soundTimer != 0 {
+		t.Errorf("expected sound timer to reach 0, got %d", cpu.soundTimer)
+	}
+	if len(cpu.Beep()) != 0 {
+		t.Errorf("expected no further beeps, got %d", len(cpu.Beep()))
+	}
+}
+
+func TestBeepsDroppedWhenNotRead(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[0] = 1
+	for i := 0; i < beepBufferSize+5; i++ {
+		if _, err := cpu.opcode0xF000(0xF018); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(cpu.Beep()) != beepBufferSize {
+		t.Errorf("expected %d buffered beeps, got %d", beepBufferSize, len(cpu.Beep()))
+	}
+
+	// Setting the timer to zero is silent
+	<-cpu.Beep()
+	cpu.V[0] = 0
+	if _, err := cpu.opcode0xF000(0xF018); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cpu.Beep()) != beepBufferSize-1 {
+		t.Errorf("expected %d buffered beeps, got %d", beepBufferSize-1, len(cpu.Beep()))
+	}
+}
+
+func TestSetClearPattern(t *testing.T) {
+	cpu := initCPU()
+	cpu.SetClearPattern([]byte{1, 0, 0})
+
+	if _, err := cpu.opcode0x0000(0x00E0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, p := range cpu.gfx {
+		var expected byte
+		if i%3 == 0 {
+			expected = 1
+		}
+		if p != expected {
+			t.Fatalf("pixel %d should be %d, got %d", i, expected, p)
+		}
+	}
+
+	cpu.SetClearPattern(nil)
+	if _, err := cpu.opcode0x0000(0x00E0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, p := range cpu.gfx {
+		if p != 0 {
+			t.Fatalf("pixel %d should be cleared, got %d", i, p)
+		}
+	}
+}
+
+func TestSetClearPatternCollision(t *testing.T) {
+	cpu := initCPU()
+	cpu.SetClearPattern([]byte{0xFF})
+	if _, err := cpu.opcode0x0000(0x00E0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu.gfx[0] != 1 {
+		t.Fatalf("pixel 0 should be 1, got %d", cpu.gfx[0])
+	}
+
+	// Draw a single pixel over the pattern
+	cpu.I = 0x300
+	cpu.memory[0x300] = 0x80
+	if _, err := cpu.opcode0xD000(0xD011); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 0xF, 1)
+	if cpu.gfx[0] != 0 {
+		t.Errorf("pixel 0 should be erased, got %d", cpu.gfx[0])
+	}
+}
+
+func TestResolution(t *testing.T) {
+	cpu := initCPU()
+	if w, h := cpu.Resolution(); w != 64 || h != 32 {
+		t.Fatalf("expected 64x32, got %dx%d", w, h)
+	}
+
+	if _, err := cpu.opcode0x0000(0x00FF); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w, h := cpu.Resolution(); w != 128 || h != 64 {
+		t.Fatalf("expected 128x64, got %dx%d", w, h)
+	}
+	if !cpu.DrawFlag() {
+		t.Errorf("expected draw flag to be set")
+	}
+
+	// Draw the top line of the "0" glyph at the bottom right
+	cpu.I = defaultFontAddress
+	cpu.V[0] = 124
+	cpu.V[1] = 63
+	if _, err := cpu.opcode0xD000(0xD011); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buffer := cpu.GetGraphicsBuffer()
+	if len(buffer) != 128*64 {
+		t.Fatalf("expected buffer of %d pixels, got %d", 128*64, len(buffer))
+	}
+	for x := 124; x < 128; x++ {
+		if buffer[63*128+x] != 1 {
+			t.Errorf("expected pixel (%d,63) to be set", x)
+		}
+	}
+	graphics := cpu.GetGraphics()
+	if graphics[31*64+62] != 1 || graphics[31*64+63] != 1 {
+		t.Errorf("expected downscaled pixels to be set")
+	}
+
+	if _, err := cpu.opcode0x0000(0x00FE); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w, h := cpu.Resolution(); w != 64 || h != 32 {
+		t.Fatalf("expected 64x32, got %dx%d", w, h)
+	}
+	for i, p := range cpu.GetGraphicsBuffer() {
+		if p != 0 {
+			t.Fatalf("expected screen to be cleared, pixel %d is %d", i, p)
+		}
+	}
+}
+
+func TestStackDepth(t *testing.T) {
+	cpu := initCPU()
+	if d := cpu.StackDepth(); d != 0 {
+		t.Fatalf("expected initial depth 0, got %d", d)
+	}
+
+	for i, opcode := range []uint16{0x2300, 0x2400} {
+		if _, err := cpu.opcode0x2000(opcode); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if d := cpu.StackDepth(); d != i+1 {
+			t.Errorf("expected depth %d after call, got %d", i+1, d)
+		}
+	}
+
+	if _, err := cpu.opcode0x0000(0x00EE); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := cpu.StackDepth(); d != 1 {
+		t.Errorf("expected depth 1 after return, got %d", d)
+	}
+	expectPC(t, cpu, 0x302)
+}
+
+func TestRunCycles(t *testing.T) {
+	t.Run("stops at self jump", func(t *testing.T) {
+		// V0 = 1; V1 = 2; goto 0x204
+		cpu, err := New(bytes.NewReader(BuildROM(0x6001, 0x6102, 0x1204)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := cpu.RunCycles(100)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(results))
+		}
+		if results[2].Opcode != 0x1204 {
+			t.Errorf("expected final opcode 0x1204, got 0x%04X", results[2].Opcode)
+		}
+		expectRegister(t, cpu, 0, 1)
+		expectRegister(t, cpu, 1, 2)
+		expectPC(t, cpu, 0x204)
+	})
+
+	t.Run("cycle limit", func(t *testing.T) {
+		// V0 += 1; goto 0x200
+		cpu, err := New(bytes.NewReader(BuildROM(0x7001, 0x1200)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := cpu.RunCycles(5)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 5 {
+			t.Errorf("expected 5 results, got %d", len(results))
+		}
+		expectRegister(t, cpu, 0, 3)
+	})
+
+	t.Run("halted", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(BuildROM(0x6001)), WithEndOfProgramBehavior(EndOfProgramHalt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := cpu.RunCycles(10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 2 || !results[1].Halted {
+			t.Errorf("expected 2 results ending with a halt, got %+v", results)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		// V0 = 1; unknown opcode
+		cpu, err := New(bytes.NewReader(BuildROM(0x6001, 0xE0FF)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := cpu.RunCycles(10)
+		if !errors.Is(err, ErrUnknownOpcode) {
+			t.Errorf("expected ErrUnknownOpcode, got %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("expected 1 result, got %d", len(results))
+		}
+	})
+}
+
+func TestKeysHeld(t *testing.T) {
+	cpu := initCPU()
+	cpu.SetKeyState(0x1, true)
+	cpu.SetKeyState(0xF, true)
+
+	expected := [16]bool{0x1: true, 0xF: true}
+	if keys := cpu.GetKeys(); keys != expected {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+
+	cpu.SetKeyState(0x1, false)
+	expected[0x1] = false
+	if keys := cpu.GetKeys(); keys != expected {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+}
+
+func TestHeldKeyPassesRepeatedChecks(t *testing.T) {
+	// V0 = 5; if key V0 is down skip; goto 0x20A; V1 += 1; goto 0x202; goto 0x20A
+	rom := BuildROM(0x6005, 0xE09E, 0x120A, 0x7101, 0x1202, 0x120A)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatal(err)
+	}
+
+	cpu.SetKeyState(0x5, true)
+	for i := 0; i < 3; i++ {
+		if _, err := cpu.Step(3); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expectRegister(t, cpu, 1, 3)
+	expectPC(t, cpu, 0x202)
+	if !cpu.GetKeys()[0x5] {
+		t.Errorf("expected key to remain held")
+	}
+
+	// Once released, the check fails
+	cpu.SetKeyState(0x5, false)
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatal(err)
+	}
+	expectPC(t, cpu, 0x204)
+}
+
+func TestHeldKeyFailsRepeatedNotPressedChecks(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[2] = 0xB
+	cpu.SetKeyState(0xB, true)
+	for i := 0; i < 3; i++ {
+		cpu.pc = 0x200
+		if _, err := cpu.opcode0xE000(0xE2A1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectPC(t, cpu, 0x202)
+	}
+}
+
+func TestKeyChecksUseLowNibble(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[0] = 0xF5
+	cpu.SetKeyState(0x5, true)
+	if _, err := cpu.opcode0xE000(0xE09E); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectPC(t, cpu, 0x204)
+
+	cpu.pc = 0x200
+	if _, err := cpu.opcode0xE000(0xE0A1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectPC(t, cpu, 0x202)
+}
+
+func TestSetKeyStateIgnoresInvalidKeys(t *testing.T) {
+	cpu := initCPU()
+	cpu.SetKeyState(0x10, true)
+	cpu.SetKeyState(0xFF, false)
+	if keys := cpu.GetKeys(); keys != [16]bool{} {
+		t.Errorf("expected no keys to be pressed, got %v", keys)
+	}
+}
+
+func TestDeprecatedKeyFunctions(t *testing.T) {
+	cpu := initCPU()
+	cpu.SetKeyDown(0x4)
+	if !cpu.GetKeys()[0x4] {
+		t.Errorf("expected SetKeyDown to press the key")
+	}
+	cpu.SetKeyUp(0x4)
+	if cpu.GetKeys()[0x4] {
+		t.Errorf("expected SetKeyUp to release the key")
+	}
+}
+
+func TestReset(t *testing.T) {
+	// V0 = 5; I = 0x300; draw(V0,V0,5); call 0x20A; V1 = 1
+	rom := BuildROM(0x6005, 0xA300, 0xD005, 0x220A, 0x0000, 0x6101)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cpu.delayTimer = 10
+	cpu.soundTimer = 10
+	cpu.SetKeyDown(0x3)
+	cpu.memory[0x300] = 0xFF
+	if !cpu.drawFlag {
+		t.Fatalf("expected the draw to set the draw flag")
+	}
+
+	cpu.Reset()
+
+	if !bytes.Equal(cpu.memory[0x200:0x200+len(rom)], rom) {
+		t.Errorf("expected ROM to be restored")
+	}
+	if cpu.memory[0x300] != 0 {
+		t.Errorf("expected memory beyond the ROM to be cleared")
+	}
+	if !bytes.Equal(cpu.memory[defaultFontAddress:defaultFontAddress+len(chip8Fontset)], chip8Fontset) {
+		t.Errorf("expected font to be loaded")
+	}
+	expectPC(t, cpu, 0x200)
+	if cpu.V != [16]byte{} || cpu.I != 0 || cpu.sp != 0 || cpu.stack != [16]uint16{} {
+		t.Errorf("expected registers and stack to be cleared")
+	}
+	if cpu.delayTimer != 0 || cpu.soundTimer != 0 {
+		t.Errorf("expected timers to be cleared")
+	}
+	if cpu.GetKeys() != [16]bool{} {
+		t.Errorf("expected keys to be released")
+	}
+	if cpu.DrawFlag() {
+		t.Errorf("expected draw flag to be cleared")
+	}
+	for i, p := range cpu.GetGraphicsBuffer() {
+		if p != 0 {
+			t.Fatalf("expected pixel %d to be cleared", i)
+		}
+	}
+
+	// The program runs again from the start
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 0, 5)
+}
+
+func TestLoadAddress(t *testing.T) {
+	// V0 = 1; V1 = 2
+	rom := BuildROM(0x6001, 0x6102)
+
+	t.Run("load address", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(rom), WithLoadAddress(0x600))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(cpu.memory[0x600:0x604], rom) {
+			t.Errorf("expected ROM at 0x600, got % X", cpu.memory[0x600:0x604])
+		}
+		if start := cpu.ProgramStart(); start != 0x600 {
+			t.Errorf("expected program start 0x600, got 0x%X", start)
+		}
+		expectPC(t, cpu, 0x600)
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectRegister(t, cpu, 0, 1)
+
+		cpu.Reset()
+		expectPC(t, cpu, 0x600)
+		if !bytes.Equal(cpu.memory[0x600:0x604], rom) {
+			t.Errorf("expected ROM at 0x600 after reset")
+		}
+	})
+
+	t.Run("entry point", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(rom), WithLoadAddress(0x600), WithEntryPoint(0x602))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectPC(t, cpu, 0x602)
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectRegister(t, cpu, 0, 0)
+		expectRegister(t, cpu, 1, 2)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		if _, err := New(bytes.NewReader(rom), WithLoadAddress(0xFFE)); err == nil {
+			t.Errorf("expected an error for a ROM past the end of memory")
+		}
+		if _, err := New(bytes.NewReader(rom), WithLoadAddress(0xFFC)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSetDrawFlag(t *testing.T) {
+	cpu := initCPU()
+	cpu.DrawFlag()
+
+	// Render whenever the flag is set, as a frontend would
+	var renders int
+	render := func() {
+		if cpu.DrawFlag() {
+			cpu.GetGraphics()
+			renders++
+		}
+	}
+
+	render()
+	if renders != 0 {
+		t.Fatalf("expected no render before the flag is set")
+	}
+
+	cpu.SetDrawFlag(true)
+	render()
+	render()
+	if renders != 1 {
+		t.Errorf("expected forced redraw to render once, got %d", renders)
+	}
+
+	// A draw can be suppressed
+	cpu.I = defaultFontAddress
+	if _, err := cpu.opcode0xD000(0xD005); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cpu.SetDrawFlag(false)
+	render()
+	if renders != 1 {
+		t.Errorf("expected suppressed draw not to render, got %d renders", renders)
+	}
+}
+
+func TestStep(t *testing.T) {
+	// V0 = 1; V0 += 2; I = 0x300; V0 = 4
+	rom := BuildROM(0x6001, 0x7002, 0xA300, 0x6004)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := cpu.Step(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var types []string
+	for _, r := range results {
+		types = append(types, r.OpcodeType)
+	}
+	expected := []string{"0x6XNN", "0x7XNN", "0xANNN"}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("expected opcode types %v, got %v", expected, types)
+	}
+	expectPC(t, cpu, 0x206)
+	expectRegister(t, cpu, 0, 3)
+}
+
+func TestStepError(t *testing.T) {
+	// V0 = 1; unknown; V0 = 2
+	rom := BuildROM(0x6001, 0xF0FF, 0x6002)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := cpu.Step(3)
+	if !errors.Is(err, ErrUnknownOpcode) {
+		t.Fatalf("expected unknown opcode error, got %v", err)
+	}
+	if len(results) != 1 || results[0].OpcodeType != "0x6XNN" {
+		t.Errorf("expected the result of the first cycle only, got %+v", results)
+	}
+	expectPC(t, cpu, 0x202)
+}
@@ -0,0 +1,91 @@
+package chip8
+
+import "time"
+
+const (
+	// Rate at which the delay and sound timers count down
+	timerPeriod = time.Second / 60
+
+	// Default number of cycles to execute per second
+	defaultClockSpeed = 300
+)
+
+// Clock provides the current time, allowing timers to be driven by
+// something other than the wall clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock reads the current time from the system.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a Clock that only moves forward when advanced explicitly,
+// for running programs deterministically. The zero value is ready to use.
+type ManualClock struct {
+	now time.Time
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// WithClock configures the machine to measure the passing of time with clock.
+// By default, the system clock is used.
+func WithClock(clock Clock) Option {
+	return func(c *Chip8) {
+		c.clock = clock
+		c.lastTick = clock.Now()
+	}
+}
+
+// SetClockSpeed sets the number of cycles per second the machine is intended to run at.
+// The caller is responsible for calling EmulateCycle at this rate, the timers count
+// down at 60Hz regardless of the clock speed.
+func (c *Chip8) SetClockSpeed(hz int) {
+	c.clockSpeed = hz
+}
+
+// ClockSpeed returns the number of cycles per second the machine is intended to run at.
+func (c *Chip8) ClockSpeed() int {
+	return c.clockSpeed
+}
+
+// WithManualTimers stops EmulateCycle from counting the timers down as time
+// passes. Instead, the caller is responsible for calling TickTimers at 60Hz,
+// independently of the rate at which cycles are executed.
+func WithManualTimers() Option {
+	return func(c *Chip8) {
+		c.manualTimers = true
+	}
+}
+
+// TickTimers counts the delay and sound timers down by one 60Hz tick.
+// This is intended for use with WithManualTimers, otherwise the timers are
+// also counted down by EmulateCycle.
+// Timers are not counted down while the machine is paused.
+func (c *Chip8) TickTimers() {
+	if c.paused {
+		return
+	}
+	c.updateTimers()
+}
+
+// tickTimers updates the timers once for every 60Hz tick that has elapsed
+// since they were last updated.
+func (c *Chip8) tickTimers() {
+	now := c.clock.Now()
+	for now.Sub(c.lastTick) >= timerPeriod {
+		c.lastTick = c.lastTick.Add(timerPeriod)
+		c.updateTimers()
+	}
+}
@@ -0,0 +1,138 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTimersFollowClock(t *testing.T) {
+	clock := &ManualClock{}
+	// V0 = 0x20; delay_timer(V0); goto 0x204
+	cpu, err := New(bytes.NewReader(BuildROM(0x6020, 0xF015, 0x1204)), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cycle := func() {
+		t.Helper()
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cycle()
+	cycle()
+
+	// Less than a tick has no effect, however many cycles run
+	clock.Advance(timerPeriod / 2)
+	for i := 0; i < 10; i++ {
+		cycle()
+	}
+	if cpu.delayTimer != 0x20 {
+		t.Errorf("expected delay timer 0x20, got 0x%X", cpu.delayTimer)
+	}
+
+	// Ticks are not dropped when cycles run slower than the timers
+	clock.Advance(timerPeriod/2 + 2*timerPeriod)
+	cycle()
+	if cpu.delayTimer != 0x1D {
+		t.Errorf("expected delay timer 0x1D, got 0x%X", cpu.delayTimer)
+	}
+}
+
+func TestTimersCatchUpInOneCycle(t *testing.T) {
+	clock := &ManualClock{}
+	// V0 = 0x20; delay_timer(V0); V1 += 1
+	cpu, err := New(bytes.NewReader(BuildROM(0x6020, 0xF015, 0x7101)), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.Step(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(5 * timerPeriod)
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu.delayTimer != 0x20-5 {
+		t.Errorf("expected delay timer 0x%X, got 0x%X", 0x20-5, cpu.delayTimer)
+	}
+}
+
+func TestClockSpeed(t *testing.T) {
+	cpu := initCPU()
+	if hz := cpu.ClockSpeed(); hz != defaultClockSpeed {
+		t.Errorf("expected default clock speed %d, got %d", defaultClockSpeed, hz)
+	}
+	cpu.SetClockSpeed(1000)
+	if hz := cpu.ClockSpeed(); hz != 1000 {
+		t.Errorf("expected clock speed 1000, got %d", hz)
+	}
+}
+
+func TestResetRestartsTimers(t *testing.T) {
+	clock := &ManualClock{}
+	// V0 = 0x20; delay_timer(V0); goto 0x204
+	cpu, err := New(bytes.NewReader(BuildROM(0x6020, 0xF015, 0x1204)), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Ticks that elapsed before the reset are not applied afterwards
+	clock.Advance(10 * timerPeriod)
+	cpu.Reset()
+	expectPC(t, cpu, 0x200)
+	expectRegister(t, cpu, 0, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if cpu.delayTimer != 0x20 {
+		t.Errorf("expected delay timer 0x20, got 0x%X", cpu.delayTimer)
+	}
+}
+
+func TestManualTimers(t *testing.T) {
+	clock := &ManualClock{}
+	// V0 = 3; delay_timer(V0); V1 = 2; sound_timer(V1); goto 0x208
+	rom := BuildROM(0x6003, 0xF015, 0x6102, 0xF118, 0x1208)
+	cpu, err := New(bytes.NewReader(rom), WithClock(clock), WithManualTimers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Cycles leave the timers alone, however much time passes
+	clock.Advance(10 * timerPeriod)
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu.delayTimer != 3 || cpu.soundTimer != 2 {
+		t.Fatalf("expected timers 3 and 2, got %d and %d", cpu.delayTimer, cpu.soundTimer)
+	}
+
+	cpu.TickTimers()
+	if cpu.delayTimer != 2 || cpu.soundTimer != 1 {
+		t.Errorf("expected timers 2 and 1, got %d and %d", cpu.delayTimer, cpu.soundTimer)
+	}
+	cpu.TickTimers()
+	if cpu.delayTimer != 1 || cpu.soundTimer != 0 {
+		t.Errorf("expected timers 1 and 0, got %d and %d", cpu.delayTimer, cpu.soundTimer)
+	}
+
+	// Timers stop at zero
+	cpu.TickTimers()
+	cpu.TickTimers()
+	if cpu.delayTimer != 0 || cpu.soundTimer != 0 {
+		t.Errorf("expected timers to stop at zero, got %d and %d", cpu.delayTimer, cpu.soundTimer)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/theothertomelliott/chip8"
+)
+
+// runGenDiagnostic writes a diagnostic ROM for the quirks of a profile,
+// followed by a legend of its checks to out, returning the exit code.
+//
+//	chip8 gen-diagnostic [-profile name] -o diag.ch8
+func runGenDiagnostic(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("gen-diagnostic", flag.ContinueOnError)
+	output := flags.String("o", "diag.ch8", "File to write the ROM to.")
+	profileName := flags.String("profile", "", "Interpreter whose quirks the ROM expects: vip, chip48, schip or xochip. If not provided, the emulator's default behavior is expected.")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	var quirks chip8.Quirks
+	if *profileName != "" {
+		p, err := chip8.ParseProfile(*profileName)
+		if err != nil {
+			log.Print(err)
+			return exitError
+		}
+		quirks = p.Quirks()
+	}
+	rom, err := chip8.DiagnosticROM(quirks)
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	if err := os.WriteFile(*output, rom, 0644); err != nil {
+		log.Print(err)
+		return exitError
+	}
+
+	fmt.Fprintln(out, "Cells are drawn in rows of 8, solid if the check passed and crossed if not:")
+	for i, name := range chip8.DiagnosticChecks() {
+		fmt.Fprintf(out, "%2d: %s\n", i+1, name)
+	}
+	return 0
+}
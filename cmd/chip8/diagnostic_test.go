@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/theothertomelliott/chip8"
+)
+
+func TestGenDiagnostic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "diag.ch8")
+	var out bytes.Buffer
+	if code := runGenDiagnostic([]string{"-profile", "schip", "-o", path}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	rom, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := chip8.DiagnosticROM(chip8.ProfileSuperChipLegacy.Quirks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rom, expected) {
+		t.Errorf("expected the diagnostic ROM for schip to be written")
+	}
+	for _, name := range chip8.DiagnosticChecks() {
+		if !strings.Contains(out.String(), name) {
+			t.Errorf("expected %q in the legend, got:\n%s", name, out.String())
+		}
+	}
+
+	if code := runGenDiagnostic([]string{"-profile", "unknown", "-o", path}, &out); code != exitError {
+		t.Errorf("expected exit code %d for an unknown profile, got %d", exitError, code)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import "sync/atomic"
+
+// frame is a copy of the display at its active resolution.
+type frame struct {
+	pixels        [128 * 64]byte
+	width, height int
+}
+
+// frameExchange passes completed frames from the emulation goroutine to the
+// render loop without either side blocking or seeing a partially written frame.
+//
+// Three buffers are used. The writer owns the back buffer, the reader owns the
+// front buffer, and the third is exchanged between them with an atomic swap.
+type frameExchange struct {
+	buffers [3]frame
+
+	// Index of the shared buffer, with freshBit set if it holds a frame
+	// not yet taken by the reader.
+	shared uint32
+
+	back  uint32 // Only accessed by the writer
+	front uint32 // Only accessed by the reader
+}
+
+const (
+	indexMask = 0x3
+	freshBit  = 0x4
+)
+
+func newFrameExchange() *frameExchange {
+	return &frameExchange{
+		front:  0,
+		shared: 1,
+		back:   2,
+	}
+}
+
+// publish makes a completed frame available to the reader.
+// publish must only be called by a single writer goroutine.
+func (f *frameExchange) publish(graphics *frame) {
+	f.buffers[f.back] = *graphics
+	previous := atomic.SwapUint32(&f.shared, f.back|freshBit)
+	f.back = previous & indexMask
+}
+
+// latest returns the most recently published frame, and whether it is
+// different to the frame returned by the previous call.
+// latest must only be called by a single reader goroutine, and the returned
+// frame is only valid until the next call.
+func (f *frameExchange) latest() (*frame, bool) {
+	if atomic.LoadUint32(&f.shared)&freshBit == 0 {
+		return &f.buffers[f.front], false
+	}
+	previous := atomic.SwapUint32(&f.shared, f.front)
+	f.front = previous & indexMask
+	return &f.buffers[f.front], true
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestFrameExchangeNoTearing(t *testing.T) {
+	const frames = 250
+	exchange := newFrameExchange()
+
+	go func() {
+		for i := 1; i <= frames; i++ {
+			var f frame
+			for p := range f.pixels {
+				f.pixels[p] = byte(i)
+			}
+			exchange.publish(&f)
+		}
+	}()
+
+	var last byte
+	var seen int
+	for seen < frames {
+		f, fresh := exchange.latest()
+		if !fresh {
+			continue
+		}
+		for p := range f.pixels {
+			if f.pixels[p] != f.pixels[0] {
+				t.Fatalf("torn frame: pixel %d is %d, pixel 0 is %d", p, f.pixels[p], f.pixels[0])
+			}
+		}
+		if f.pixels[0] == last {
+			t.Fatalf("frame %d returned as fresh twice", f.pixels[0])
+		}
+		last = f.pixels[0]
+		seen++
+		if last == byte(frames) {
+			break
+		}
+	}
+}
+
+func TestFrameExchangeLatest(t *testing.T) {
+	exchange := newFrameExchange()
+	if _, fresh := exchange.latest(); fresh {
+		t.Errorf("expected no fresh frame before publishing")
+	}
+
+	exchange.publish(&frame{width: 1})
+	exchange.publish(&frame{width: 2})
+	f, fresh := exchange.latest()
+	if !fresh || f.width != 2 {
+		t.Errorf("expected fresh frame 2, got %d (fresh=%v)", f.width, fresh)
+	}
+	f, fresh = exchange.latest()
+	if fresh || f.width != 2 {
+		t.Errorf("expected stale frame 2, got %d (fresh=%v)", f.width, fresh)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// latencyStats measures the time from a key press being read to the
+// next frame being presented.
+type latencyStats struct {
+	pending time.Time
+	total   time.Duration
+	count   int
+}
+
+// pressed records that a key press has been read.
+func (l *latencyStats) pressed() {
+	if l.pending.IsZero() {
+		l.pending = time.Now()
+	}
+}
+
+// presented records that a new frame has been shown.
+func (l *latencyStats) presented() {
+	if l.pending.IsZero() {
+		return
+	}
+	l.total += time.Since(l.pending)
+	l.count++
+	l.pending = time.Time{}
+}
+
+func (l *latencyStats) String() string {
+	if l.count == 0 {
+		return "input latency: no key presses measured"
+	}
+	return fmt.Sprintf("input latency: %v average over %d key presses", l.total/time.Duration(l.count), l.count)
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/faiface/pixel"
@@ -17,51 +19,201 @@ import (
 )
 
 const (
-	cyclesPerSecond           = 300
-	sizeX, sizeY              = 64, 32
 	screenWidth, screenHeight = float64(1024), float64(768)
-	keyRepeatDuration         = time.Second / 5
+)
+
+// Exit codes
+const (
+	exitError    = 1 // Emulator or usage error
+	exitROMFault = 2 // The ROM performed an invalid operation
+	exitLimit    = 3 // The ROM did not halt within a limit, such as -cycles
 )
 
 var (
-	win         *pixelgl.Window
-	listOpcodes = flag.Bool("listOpcodes", false, "If provided, a list of opcodes used by a ROM while executing will be output on exit.")
+	win           *pixelgl.Window
+	listOpcodes   = flag.Bool("listOpcodes", false, "If provided, a list of opcodes used by a ROM while executing will be output on exit.")
+	lowLatency    = flag.Bool("low-latency", false, "If provided, emulation runs independently of screen refresh to reduce input latency.")
+	reportLatency = flag.Bool("reportLatency", false, "If provided, the average time from key press to screen update will be output on exit.")
+	clockSpeed    = flag.Int("speed", 0, "Cycles to execute per second. If not provided, the emulator's default speed is used.")
+	profile       = flag.String("profile", "", "Interpreter whose quirks the ROM expects: vip, chip48, schip or xochip. If not provided, the emulator's default behavior is used.")
+	headless      = flag.Bool("headless", false, "If provided, the ROM is run without a window until it halts, as fast as possible.")
+	maxCycles     = flag.Int("cycles", 10000000, "Maximum number of cycles to run with -headless before giving up.")
+	protect       = flag.Bool("protect", true, "If true, the memory editor refuses to write below the program start, where the fonts are stored.")
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gen-diagnostic" {
+		os.Exit(runGenDiagnostic(os.Args[2:], os.Stdout))
+	}
+	flag.Parse()
+	if *headless {
+		os.Exit(runHeadless())
+	}
 	pixelgl.Run(run)
 }
 
-func run() {
+// runHeadless runs the ROM without a display until it halts, returning the
+// exit code for the outcome.
+func runHeadless() int {
+	myChip8, err := loadMachine()
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	defer myChip8.Close()
 
-	ticker := time.NewTicker(time.Second / cyclesPerSecond)
-	defer ticker.Stop()
+	if err := runToHalt(myChip8, *maxCycles); err != nil {
+		log.Print(err)
+		return exitCode(err)
+	}
+	return 0
+}
 
-	// Set up render system and register input callbacks
-	setupGraphics()
+// runToHalt emulates up to maxCycles cycles, until the program halts.
+// Only the latest Result is kept, so long runs use constant memory.
+func runToHalt(c *chip8.Chip8, maxCycles int) error {
+	for i := 0; i < maxCycles; i++ {
+		result, err := c.EmulateCycle()
+		if err != nil {
+			return err
+		}
+		if result.Halted {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: not halted after %d cycles", chip8.ErrLimitExceeded, maxCycles)
+}
 
-	flag.Parse()
+// loadMachine creates a CHIP-8 machine running the ROM named on the command line.
+func loadMachine() (*chip8.Chip8, error) {
+	if flag.NArg() == 0 {
+		return nil, errors.New("no ROM specified")
+	}
+	// Open the ROM specified as argument ready to load
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
+	var opts []chip8.Option
+	if *profile != "" {
+		p, err := chip8.ParseProfile(*profile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, chip8.WithProfile(p))
+	}
+	return chip8.New(file, opts...)
+}
+
+// emulator wraps a CHIP-8 machine with the frontend's record of its execution.
+type emulator struct {
+	chip8 *chip8.Chip8
+
+	// Should trace logging be output?
+	trace bool
 	// Record usage of particular opcodes
-	var opcodesUsed = make(map[string]struct{})
+	opcodesUsed map[string]struct{}
+	// Has the program halted? The final display is kept on screen.
+	halted bool
+	// Memory editor shown in the overlay, if any
+	editor *memoryEditor
+}
 
-	// Open the ROM specified as argument ready to load
-	file, err := os.Open(flag.Args()[0])
+// step emulates one cycle, recording and optionally logging the result.
+// Once the program halts, no further cycles are emulated.
+func (e *emulator) step() error {
+	if e.halted {
+		return nil
+	}
+	result, err := e.chip8.EmulateCycle()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	e.halted = result.Halted
+	if e.editor != nil {
+		e.editor.overwritten(result.WatchpointHits)
+	}
+	// Record that this type of opcode was used
+	e.opcodesUsed[result.OpcodeType] = struct{}{}
+	return nil
+}
+
+// toggleTrace turns trace logging on or off. Cycles are only described
+// while tracing, so logging costs nothing when it is off.
+func (e *emulator) toggleTrace() {
+	e.trace = !e.trace
+	if !e.trace {
+		e.chip8.SetTracer(nil)
+		return
+	}
+	e.chip8.SetTracer(func(result chip8.Result) {
+		log.Printf("0x%X> (0x%X) %s", result.Before.PC, result.Opcode, result.Pseudo)
+	})
+}
+
+func run() {
+	// Set up render system and register input callbacks
+	setupGraphics()
 
 	// Create a CHIP-8 machine and load the ROM file
-	myChip8, err := chip8.New(file)
-	_ = file.Close()
+	myChip8, err := loadMachine()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	defer myChip8.Close()
+
+	if *clockSpeed > 0 {
+		myChip8.SetClockSpeed(*clockSpeed)
+	}
+
 	go handleBeeps(myChip8)
 
-	// Should trace logging be output?
-	var trace bool
+	emu := &emulator{
+		chip8:       myChip8,
+		opcodesUsed: make(map[string]struct{}),
+		editor:      newMemoryEditor(myChip8, myChip8.ProgramStart(), *protect),
+	}
+	var latency latencyStats
+
+	start := time.Now()
+	if *lowLatency {
+		err = runLowLatency(emu, &latency)
+	} else {
+		err = runSynchronous(emu, &latency)
+	}
+	// Summarize the session, even if it ended in a fault
+	log.Printf("session: %s", myChip8.MetricsSummary().Format(time.Since(start)))
+	if err != nil {
+		log.Print(err)
+		os.Exit(exitCode(err))
+	}
+
+	if *listOpcodes {
+		var opcodes []string
+		for opcode := range emu.opcodesUsed {
+			opcodes = append(opcodes, opcode)
+		}
+		sort.Strings(opcodes)
+		for _, opcode := range opcodes {
+			fmt.Println(opcode)
+		}
+	}
+	if *reportLatency {
+		fmt.Println(latency.String())
+	}
+}
+
+// runSynchronous emulates one cycle per iteration of the render loop.
+func runSynchronous(emu *emulator, latency *latencyStats) error {
+	ticker := time.NewTicker(cyclePeriod(emu.chip8))
+	defer ticker.Stop()
+
+	graphics := captureFrame(emu.chip8)
+	showMemory := false
+	var lastOverlay time.Time
 
 	// Emulation loop
 	for !win.Closed() {
@@ -70,44 +222,176 @@ func run() {
 		}
 		// Toggle operation tracing
 		if win.JustPressed(pixelgl.KeyT) {
-			trace = !trace
+			emu.toggleTrace()
+		}
+		// Toggle the memory editor
+		toggled := win.JustPressed(pixelgl.KeyM)
+		if toggled {
+			showMemory = !showMemory
+			emu.editor.cancel()
+			releaseKeys(emu.chip8.SetKeyState)
 		}
 
 		// Emulate one cycle
-		result, err := myChip8.EmulateCycle()
-		if err != nil {
-			log.Fatalf("0x%X> %v", result.Before.PC, err)
-		}
-		// Record that this type of opcode was used
-		opcodesUsed[result.OpcodeType] = struct{}{}
-		// Log this step if tracing is enabled
-		if trace {
-			log.Printf("0x%X> (0x%X) %s", result.Before.PC, result.Opcode, result.Pseudo)
+		if err := emu.step(); err != nil {
+			return err
 		}
 
-		// If the draw flag is set, update the screen
-		if myChip8.DrawFlag() {
-			drawGraphics(myChip8.GetGraphics())
+		// If the draw flag is set, update the screen. The memory editor is
+		// also redrawn at the overlay's refresh rate, to show its edits and
+		// the program's writes.
+		drawn := emu.chip8.DrawFlag()
+		if drawn {
+			graphics = captureFrame(emu.chip8)
+		}
+		if drawn || toggled || (showMemory && time.Since(lastOverlay) >= overlayRefresh) {
+			var overlay *memoryView
+			if showMemory {
+				v := emu.editor.view()
+				overlay = &v
+				lastOverlay = time.Now()
+			}
+			drawGraphics(graphics, overlay)
+			if drawn {
+				latency.presented()
+			}
 		} else {
 			win.UpdateInput()
 		}
 
-		handleKeys(myChip8)
+		// The keypad is suspended while editing memory
+		if showMemory {
+			handleEditorKeys(func(action func(e *memoryEditor)) { action(emu.editor) })
+		} else {
+			handleKeys(emu.chip8.SetKeyState, latency)
+		}
 
 		// Wait for the next tick
 		<-ticker.C
 	}
+	return nil
+}
 
-	if *listOpcodes {
-		var opcodes []string
-		for opcode := range opcodesUsed {
-			opcodes = append(opcodes, opcode)
+// runLowLatency emulates on a separate goroutine from the render loop.
+// The render loop passes commands such as key presses to the emulation
+// goroutine as soon as they are read, and draws the latest completed frame
+// at the screen's refresh rate.
+func runLowLatency(emu *emulator, latency *latencyStats) error {
+	frames := newFrameExchange()
+	// The memory editor's view, published by the emulation goroutine while shown
+	var showMemory atomic.Bool
+	var views atomic.Value
+	views.Store(emu.editor.view())
+	commands := make(chan func(), 64)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(cyclePeriod(emu.chip8))
+		defer ticker.Stop()
+
+		for {
+			// Apply any pending commands before the next cycle
+		pending:
+			for {
+				select {
+				case command := <-commands:
+					command()
+				default:
+					break pending
+				}
+			}
+
+			if err := emu.step(); err != nil {
+				errs <- err
+				return
+			}
+			if emu.chip8.DrawFlag() {
+				frames.publish(captureFrame(emu.chip8))
+			}
+			if showMemory.Load() {
+				views.Store(emu.editor.view())
+			}
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
 		}
-		sort.Strings(opcodes)
-		for _, opcode := range opcodes {
-			fmt.Println(opcode)
+	}()
+
+	var err error
+	for !win.Closed() {
+		if win.Pressed(pixelgl.KeyEscape) {
+			break
+		}
+		// Toggle operation tracing
+		if win.JustPressed(pixelgl.KeyT) {
+			commands <- emu.toggleTrace
+		}
+		// Toggle the memory editor
+		if win.JustPressed(pixelgl.KeyM) {
+			showMemory.Store(!showMemory.Load())
+			commands <- emu.editor.cancel
+			releaseKeys(func(index byte, down bool) {
+				commands <- func() { emu.chip8.SetKeyState(index, down) }
+			})
+		}
+		select {
+		case err = <-errs:
+		default:
+		}
+		if err != nil {
+			break
+		}
+
+		// Redraw every frame, so VSync paces this loop
+		graphics, fresh := frames.latest()
+		var overlay *memoryView
+		if showMemory.Load() {
+			v := views.Load().(memoryView)
+			overlay = &v
+		}
+		drawGraphics(graphics, overlay)
+		if fresh {
+			latency.presented()
+		}
+
+		// The keypad is suspended while editing memory
+		if showMemory.Load() {
+			handleEditorKeys(func(action func(e *memoryEditor)) {
+				commands <- func() { action(emu.editor) }
+			})
+		} else {
+			handleKeys(func(index byte, down bool) {
+				commands <- func() { emu.chip8.SetKeyState(index, down) }
+			}, latency)
 		}
 	}
+
+	close(done)
+	<-stopped
+	return err
+}
+
+// cyclePeriod returns the time between cycles at the machine's clock speed.
+func cyclePeriod(c *chip8.Chip8) time.Duration {
+	return time.Second / time.Duration(c.ClockSpeed())
+}
+
+// exitCode distinguishes faults in the ROM being run and exceeded limits
+// from other errors.
+func exitCode(err error) int {
+	switch {
+	case chip8.IsROMFault(err):
+		return exitROMFault
+	case errors.Is(err, chip8.ErrLimitExceeded):
+		return exitLimit
+	}
+	return exitError
 }
 
 func handleBeeps(c *chip8.Chip8) {
@@ -117,9 +401,8 @@ func handleBeeps(c *chip8.Chip8) {
 	}
 	defer player.Close()
 
-	tone := wavegenerator.NewTone(time.Second/4, 440, wavegenerator.Triangle)
-	for range c.Beep() {
-		player.Play(tone)
+	for beep := range c.Beep() {
+		player.Play(wavegenerator.NewTone(beep.Duration, 440, wavegenerator.Triangle))
 	}
 }
 
@@ -136,53 +419,63 @@ func setupGraphics() {
 	}
 }
 
-// Store key press state (Press and Release)
-var (
-	keyByIndex = map[uint16]pixelgl.Button{
-		0x1: pixelgl.Key1, 0x2: pixelgl.Key2, 0x3: pixelgl.Key3, 0xC: pixelgl.Key4,
-		0x4: pixelgl.KeyQ, 0x5: pixelgl.KeyW, 0x6: pixelgl.KeyE, 0xD: pixelgl.KeyR,
-		0x7: pixelgl.KeyA, 0x8: pixelgl.KeyS, 0x9: pixelgl.KeyD, 0xE: pixelgl.KeyF,
-		0xA: pixelgl.KeyZ, 0x0: pixelgl.KeyX, 0xB: pixelgl.KeyC, 0xF: pixelgl.KeyV,
-	}
-	keysDown [16]*time.Ticker
-)
-
-func handleKeys(myChip8 *chip8.Chip8) {
+// Keyboard keys mapped to the CHIP-8 keypad
+var keyByIndex = map[uint16]pixelgl.Button{
+	0x1: pixelgl.Key1, 0x2: pixelgl.Key2, 0x3: pixelgl.Key3, 0xC: pixelgl.Key4,
+	0x4: pixelgl.KeyQ, 0x5: pixelgl.KeyW, 0x6: pixelgl.KeyE, 0xD: pixelgl.KeyR,
+	0x7: pixelgl.KeyA, 0x8: pixelgl.KeyS, 0x9: pixelgl.KeyD, 0xE: pixelgl.KeyF,
+	0xA: pixelgl.KeyZ, 0x0: pixelgl.KeyX, 0xB: pixelgl.KeyC, 0xF: pixelgl.KeyV,
+}
 
+// handleKeys passes presses and releases of keyboard keys to the keypad.
+func handleKeys(setKeyState func(index byte, down bool), latency *latencyStats) {
 	for index, key := range keyByIndex {
 		if win.JustReleased(key) {
-			if keysDown[index] != nil {
-				keysDown[index].Stop()
-				keysDown[index] = nil
-			}
+			setKeyState(byte(index), false)
 		} else if win.JustPressed(key) {
-			if keysDown[index] == nil {
-				keysDown[index] = time.NewTicker(keyRepeatDuration)
-			}
-			myChip8.SetKeyDown(byte(index))
-		}
-
-		if keysDown[index] == nil {
-			continue
-		}
-		select {
-		case <-keysDown[index].C:
-			myChip8.SetKeyDown(byte(index))
-		default:
+			setKeyState(byte(index), true)
+			latency.pressed()
 		}
+	}
+}
 
+// releaseKeys releases every key of the keypad, so that no key is left held
+// while the keypad is suspended.
+func releaseKeys(setKeyState func(index byte, down bool)) {
+	for index := range keyByIndex {
+		setKeyState(byte(index), false)
 	}
 }
 
-func drawGraphics(graphics [64 * 32]byte) {
+// captureFrame copies the current display of a machine.
+func captureFrame(c *chip8.Chip8) *frame {
+	f := &frame{}
+	f.width, f.height = c.Resolution()
+	copy(f.pixels[:], c.GetGraphicsBuffer())
+	return f
+}
+
+// palette maps the XO-CHIP color index of a pixel to the color it is drawn in.
+// Classic programs only use the first plane, so only draw in white.
+var palette = []pixel.RGBA{
+	1: pixel.RGB(1, 1, 1),
+	2: pixel.RGB(0.6, 0.6, 0.6),
+	3: pixel.RGB(0.3, 0.3, 0.3),
+}
+
+// drawGraphics draws a frame of the display, and the memory editor over it
+// if overlay is not nil.
+func drawGraphics(graphics *frame, overlay *memoryView) {
 	win.Clear(colornames.Black)
 	imd := imdraw.New(nil)
-	imd.Color = pixel.RGB(1, 1, 1)
 	screenWidth := win.Bounds().W()
-	width, height := screenWidth/sizeX, screenHeight/sizeY
-	for x := 0; x < 64; x++ {
-		for y := 0; y < 32; y++ {
-			if graphics[(31-y)*64+x] == 1 {
+	sizeX, sizeY := graphics.width, graphics.height
+	width, height := screenWidth/float64(sizeX), screenHeight/float64(sizeY)
+	for x := 0; x < sizeX; x++ {
+		for y := 0; y < sizeY; y++ {
+			p := graphics.pixels[(sizeY-1-y)*sizeX+x]
+			if p != 0 && int(p) < len(palette) {
+				imd.Color = palette[p]
 				imd.Push(pixel.V(width*float64(x), height*float64(y)))
 				imd.Push(pixel.V(width*float64(x)+width, height*float64(y)+height))
 				imd.Rectangle(0)
@@ -190,5 +483,8 @@ func drawGraphics(graphics [64 * 32]byte) {
 		}
 	}
 	imd.Draw(win)
+	if overlay != nil {
+		drawMemoryView(overlay)
+	}
 	win.Update()
 }
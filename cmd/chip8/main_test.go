@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/theothertomelliott/chip8"
+)
+
+func TestExitCode(t *testing.T) {
+	var tests = []struct {
+		err      error
+		expected int
+	}{
+		{err: errors.New("emulator error"), expected: exitError},
+		{err: &chip8.Error{Err: chip8.ErrClosed}, expected: exitError},
+		{err: &chip8.Error{Err: chip8.ErrUnknownOpcode}, expected: exitROMFault},
+		{err: &chip8.Error{Err: chip8.ErrStalled}, expected: exitLimit},
+		{err: fmt.Errorf("%w: not halted", chip8.ErrLimitExceeded), expected: exitLimit},
+	}
+	for _, test := range tests {
+		if code := exitCode(test.err); code != test.expected {
+			t.Errorf("%v: expected exit code %d, got %d", test.err, test.expected, code)
+		}
+	}
+}
+
+func TestRunToHalt(t *testing.T) {
+	// V0 += 1; goto 0x204; goto 0x204
+	halting, err := chip8.New(bytes.NewReader(chip8.BuildROM(0x7001, 0x1204, 0x1204)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runToHalt(halting, 10); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// V0 += 1; goto 0x200, which never halts
+	looping, err := chip8.New(bytes.NewReader(chip8.BuildROM(0x7001, 0x1200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runToHalt(looping, 1000000); !errors.Is(err, chip8.ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+func TestToggleTrace(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	log.SetFlags(0)
+	defer log.SetFlags(log.LstdFlags)
+
+	// V0 = 1; V1 = 2; V2 = 3
+	c, err := chip8.New(bytes.NewReader(chip8.BuildROM(0x6001, 0x6102, 0x6203)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emu := &emulator{chip8: c, opcodesUsed: make(map[string]struct{})}
+	for _, trace := range []bool{false, true, false} {
+		if trace != emu.trace {
+			emu.toggleTrace()
+		}
+		if err := emu.step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "0x202> (0x6102) V1 = 0x2\n"
+	if out.String() != expected {
+		t.Errorf("expected only the second cycle to be traced, got %q", out.String())
+	}
+}
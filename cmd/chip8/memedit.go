@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Layout of the memory shown by the editor
+const (
+	memoryColumns = 8    // Bytes on each row
+	memoryRows    = 16   // Rows shown at once
+	memorySize    = 4096 // Bytes of addressable memory
+)
+
+// editableMemory is the access to a machine's memory needed by the memory
+// editor, as provided by *chip8.Chip8.
+type editableMemory interface {
+	PeekByte(addr uint16) (byte, error)
+	PokeByte(addr uint16, value byte) error
+	AddWatchpoint(addr uint16)
+	RemoveWatchpoint(addr uint16)
+}
+
+// memoryEditor is the state of the memory editor: the selected byte, the
+// hex digits typed for it and the edits made so far.
+// It does not depend on how it is drawn or which keys drive it.
+type memoryEditor struct {
+	mem editableMemory
+	// Writes below start are refused if protect is set
+	start   uint16
+	protect bool
+
+	cursor uint16
+	// First address shown, always at the start of a row
+	top uint16
+
+	// Hex digits typed for the selected byte while editing
+	editing bool
+	input   string
+	// Why the last edit was refused, if it was
+	message string
+
+	// Addresses written by the editor and not overwritten by the program since
+	edits map[uint16]struct{}
+}
+
+func newMemoryEditor(mem editableMemory, start uint16, protect bool) *memoryEditor {
+	e := &memoryEditor{
+		mem:     mem,
+		start:   start,
+		protect: protect,
+		edits:   make(map[uint16]struct{}),
+	}
+	// Show the program start at the top
+	e.top = start - start%memoryColumns
+	e.moveTo(start)
+	return e
+}
+
+// move selects the byte delta bytes from the selected byte, stopping at
+// the ends of memory. The selection cannot move while editing.
+func (e *memoryEditor) move(delta int) {
+	if e.editing {
+		return
+	}
+	addr := int(e.cursor) + delta
+	if addr < 0 {
+		addr = 0
+	}
+	if addr >= memorySize {
+		addr = memorySize - 1
+	}
+	e.moveTo(uint16(addr))
+}
+
+// moveTo selects the byte at addr, scrolling to keep it shown.
+func (e *memoryEditor) moveTo(addr uint16) {
+	e.cursor = addr
+	row := addr - addr%memoryColumns
+	if row < e.top {
+		e.top = row
+	}
+	if last := e.top + (memoryRows-1)*memoryColumns; row > last {
+		e.top = row - (memoryRows-1)*memoryColumns
+	}
+}
+
+// begin starts editing the selected byte.
+func (e *memoryEditor) begin() {
+	e.editing = true
+	e.input = ""
+	e.message = ""
+}
+
+// typeRune adds a hex digit to the value being typed, writing the value once
+// both of its digits have been typed. Other runes are ignored, as is any
+// typing when not editing.
+func (e *memoryEditor) typeRune(r rune) {
+	if !e.editing {
+		return
+	}
+	if _, err := strconv.ParseUint(string(r), 16, 8); err != nil {
+		return
+	}
+	e.input += string(r)
+	if len(e.input) < 2 {
+		return
+	}
+	value, _ := strconv.ParseUint(e.input, 16, 8)
+	e.cancel()
+	e.write(byte(value))
+}
+
+// erase removes the last digit typed, or stops editing if there are none.
+func (e *memoryEditor) erase() {
+	if e.input == "" {
+		e.cancel()
+		return
+	}
+	e.input = e.input[:len(e.input)-1]
+}
+
+// cancel stops editing without writing a value.
+func (e *memoryEditor) cancel() {
+	e.editing = false
+	e.input = ""
+}
+
+// write sets the selected byte to value, unless it is protected.
+func (e *memoryEditor) write(value byte) {
+	addr := e.cursor
+	if e.protect && addr < e.start {
+		e.message = fmt.Sprintf("0x%03X is protected: below the program start at 0x%03X", addr, e.start)
+		return
+	}
+	if err := e.mem.PokeByte(addr, value); err != nil {
+		e.message = err.Error()
+		return
+	}
+	e.message = ""
+	// Watch the edit, so it is no longer highlighted once the program overwrites it
+	if _, ok := e.edits[addr]; !ok {
+		e.edits[addr] = struct{}{}
+		e.mem.AddWatchpoint(addr)
+	}
+}
+
+// overwritten stops highlighting any edits at addrs, which the program has
+// written to.
+func (e *memoryEditor) overwritten(addrs []uint16) {
+	for _, addr := range addrs {
+		if _, ok := e.edits[addr]; !ok {
+			continue
+		}
+		delete(e.edits, addr)
+		e.mem.RemoveWatchpoint(addr)
+	}
+}
+
+// memoryView is a snapshot of the memory editor, ready to be drawn.
+type memoryView struct {
+	rows    []memoryRow
+	editing bool
+	input   string
+	message string
+}
+
+// memoryRow is a row of bytes in a memoryView.
+type memoryRow struct {
+	addr  uint16
+	cells [memoryColumns]memoryCell
+}
+
+// memoryCell is a single byte in a memoryView.
+type memoryCell struct {
+	value    byte
+	selected bool
+	edited   bool
+}
+
+// view returns the rows of memory currently shown, with the selected and
+// edited bytes marked.
+func (e *memoryEditor) view() memoryView {
+	v := memoryView{
+		editing: e.editing,
+		input:   e.input,
+		message: e.message,
+	}
+	for r := 0; r < memoryRows; r++ {
+		row := memoryRow{addr: e.top + uint16(r*memoryColumns)}
+		if int(row.addr) >= memorySize {
+			break
+		}
+		for i := range row.cells {
+			addr := row.addr + uint16(i)
+			value, _ := e.mem.PeekByte(addr)
+			_, edited := e.edits[addr]
+			row.cells[i] = memoryCell{
+				value:    value,
+				selected: addr == e.cursor,
+				edited:   edited,
+			}
+		}
+		v.rows = append(v.rows, row)
+	}
+	return v
+}
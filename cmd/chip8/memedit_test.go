@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/theothertomelliott/chip8"
+)
+
+func newTestEditor(t *testing.T, rom []byte, protect bool) (*memoryEditor, *chip8.Chip8) {
+	t.Helper()
+	c, err := chip8.New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newMemoryEditor(c, c.ProgramStart(), protect), c
+}
+
+// selected returns the address and cell of the selected byte in v.
+func selected(v memoryView) (uint16, memoryCell, bool) {
+	for _, row := range v.rows {
+		for i, cell := range row.cells {
+			if cell.selected {
+				return row.addr + uint16(i), cell, true
+			}
+		}
+	}
+	return 0, memoryCell{}, false
+}
+
+func TestMemoryEditorNavigation(t *testing.T) {
+	e, _ := newTestEditor(t, chip8.BuildROM(0x00E0), true)
+	v := e.view()
+	if len(v.rows) != memoryRows || v.rows[0].addr != 0x200 {
+		t.Fatalf("expected %d rows from 0x200, got %d from 0x%X", memoryRows, len(v.rows), v.rows[0].addr)
+	}
+	if addr, cell, _ := selected(v); addr != 0x200 || cell.value != 0x00 {
+		t.Errorf("expected 0x00 at 0x200 to be selected, got 0x%02X at 0x%X", cell.value, addr)
+	}
+
+	var tests = []struct {
+		delta    int
+		expected uint16
+		top      uint16
+	}{
+		{delta: 1, expected: 0x201, top: 0x200},
+		{delta: memoryColumns, expected: 0x209, top: 0x200},
+		{delta: -memoryColumns * 2, expected: 0x1F9, top: 0x1F8},
+		// Scrolls down to keep the selection on the last row
+		{delta: memoryColumns * memoryRows, expected: 0x279, top: 0x200},
+		// Stops at the ends of memory
+		{delta: -memorySize, expected: 0x000, top: 0x000},
+		{delta: memorySize, expected: 0xFFF, top: memorySize - memoryColumns*memoryRows},
+	}
+	for _, test := range tests {
+		e.move(test.delta)
+		v := e.view()
+		if addr, _, _ := selected(v); addr != test.expected {
+			t.Errorf("move %d: expected 0x%X to be selected, got 0x%X", test.delta, test.expected, addr)
+		}
+		if v.rows[0].addr != test.top {
+			t.Errorf("move %d: expected rows from 0x%X, got 0x%X", test.delta, test.top, v.rows[0].addr)
+		}
+	}
+	if v := e.view(); len(v.rows) != memoryRows {
+		t.Errorf("expected %d rows at the end of memory, got %d", memoryRows, len(v.rows))
+	}
+}
+
+func TestMemoryEditorInput(t *testing.T) {
+	e, c := newTestEditor(t, chip8.BuildROM(0x00E0), true)
+
+	// Typing does nothing until editing starts
+	e.typeRune('A')
+	e.typeRune('B')
+	if value, _ := c.PeekByte(0x200); value != 0x00 {
+		t.Fatalf("expected no write before editing, got 0x%02X", value)
+	}
+
+	e.begin()
+	e.typeRune('x') // Not a hex digit
+	e.typeRune('a')
+	if v := e.view(); !v.editing || v.input != "a" {
+		t.Fatalf("expected input %q while editing, got %q (editing %v)", "a", v.input, v.editing)
+	}
+	// The selection is fixed while editing
+	e.move(1)
+	e.erase()
+	e.typeRune('4')
+	if v := e.view(); v.input != "4" {
+		t.Fatalf("expected input %q after erasing, got %q", "4", v.input)
+	}
+	e.typeRune('2')
+	if value, _ := c.PeekByte(0x200); value != 0x42 {
+		t.Errorf("expected 0x42 to be written to 0x200, got 0x%02X", value)
+	}
+	v := e.view()
+	if v.editing || v.input != "" {
+		t.Errorf("expected editing to end once written, got input %q", v.input)
+	}
+	if addr, cell, _ := selected(v); addr != 0x200 || cell.value != 0x42 || !cell.edited {
+		t.Errorf("expected 0x42 at 0x200 to be highlighted, got %+v at 0x%X", cell, addr)
+	}
+	if !v.rows[0].cells[0].edited || v.rows[0].cells[1].edited {
+		t.Errorf("expected only 0x200 to be highlighted")
+	}
+
+	// Cancelling leaves memory unchanged
+	e.move(1)
+	e.begin()
+	e.typeRune('F')
+	e.cancel()
+	e.typeRune('F')
+	if value, _ := c.PeekByte(0x201); value != 0xE0 {
+		t.Errorf("expected 0x201 to be unchanged, got 0x%02X", value)
+	}
+
+	// Erasing with no input stops editing
+	e.begin()
+	e.erase()
+	if e.view().editing {
+		t.Errorf("expected editing to stop")
+	}
+}
+
+func TestMemoryEditorProtection(t *testing.T) {
+	for _, protect := range []bool{true, false} {
+		e, c := newTestEditor(t, chip8.BuildROM(0x00E0), protect)
+		before, _ := c.PeekByte(0x1FF)
+		e.move(-1)
+		e.begin()
+		e.typeRune('1')
+		e.typeRune('2')
+
+		value, _ := c.PeekByte(0x1FF)
+		v := e.view()
+		_, cell, _ := selected(v)
+		if protect {
+			if value != before || cell.edited {
+				t.Errorf("expected the write to 0x1FF to be refused, got 0x%02X", value)
+			}
+			if !strings.Contains(v.message, "0x1FF is protected") {
+				t.Errorf("expected a message explaining the refusal, got %q", v.message)
+			}
+			// Starting another edit clears the message
+			e.begin()
+			if e.view().message != "" {
+				t.Errorf("expected the message to be cleared")
+			}
+			continue
+		}
+		if value != 0x12 || !cell.edited || v.message != "" {
+			t.Errorf("expected 0x12 to be written to 0x1FF without protection, got 0x%02X (%q)", value, v.message)
+		}
+	}
+}
+
+func TestMemoryEditorOverwritten(t *testing.T) {
+	// V0 = 0x55; I = 0x300; reg_dump(V0); goto 0x206
+	e, c := newTestEditor(t, chip8.BuildROM(0x6055, 0xA300, 0xF055, 0x1206), true)
+	emu := &emulator{chip8: c, editor: e, opcodesUsed: make(map[string]struct{})}
+
+	e.move(0x100)
+	e.begin()
+	e.typeRune('7')
+	e.typeRune('7')
+	if _, cell, _ := selected(e.view()); !cell.edited {
+		t.Fatalf("expected the edit at 0x300 to be highlighted")
+	}
+
+	// The edit stays highlighted until the program writes to it
+	for i := 0; i < 2; i++ {
+		if err := emu.step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, cell, _ := selected(e.view()); !cell.edited || cell.value != 0x77 {
+		t.Fatalf("expected the edit to be highlighted before it is overwritten, got %+v", cell)
+	}
+	if err := emu.step(); err != nil {
+		t.Fatal(err)
+	}
+	if _, cell, _ := selected(e.view()); cell.edited || cell.value != 0x55 {
+		t.Errorf("expected the overwritten edit not to be highlighted, got %+v", cell)
+	}
+}
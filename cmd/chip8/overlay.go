@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/basicfont"
+)
+
+// Memory editor overlay layout
+const (
+	overlayScale  = 2  // Scale of the text
+	overlayMargin = 16 // Space around the text, in pixels
+
+	// How often the memory editor is redrawn when the display is not
+	overlayRefresh = time.Second / 30
+)
+
+var overlayAtlas = text.NewAtlas(basicfont.Face7x13, text.ASCII)
+
+// handleEditorKeys passes presses of keyboard keys to the memory editor as
+// actions for apply to run.
+// The arrow keys move by a byte or a row, Page Up and Page Down by a screen,
+// Enter starts editing the selected byte and Backspace erases a digit or
+// cancels the edit.
+func handleEditorKeys(apply func(action func(e *memoryEditor))) {
+	moves := map[pixelgl.Button]int{
+		pixelgl.KeyLeft:     -1,
+		pixelgl.KeyRight:    1,
+		pixelgl.KeyUp:       -memoryColumns,
+		pixelgl.KeyDown:     memoryColumns,
+		pixelgl.KeyPageUp:   -memoryColumns * memoryRows,
+		pixelgl.KeyPageDown: memoryColumns * memoryRows,
+	}
+	for key, delta := range moves {
+		if win.JustPressed(key) || win.Repeated(key) {
+			delta := delta
+			apply(func(e *memoryEditor) { e.move(delta) })
+		}
+	}
+	if win.JustPressed(pixelgl.KeyEnter) {
+		apply((*memoryEditor).begin)
+	}
+	if win.JustPressed(pixelgl.KeyBackspace) || win.Repeated(pixelgl.KeyBackspace) {
+		apply((*memoryEditor).erase)
+	}
+	if typed := win.Typed(); typed != "" {
+		apply(func(e *memoryEditor) {
+			for _, r := range typed {
+				e.typeRune(r)
+			}
+		})
+	}
+}
+
+// drawMemoryView draws the memory editor in a panel over the display.
+// The selected byte is yellow, showing the digits typed while editing, and
+// edits are red until the program overwrites them.
+func drawMemoryView(v *memoryView) {
+	txt := text.New(pixel.V(0, 0), overlayAtlas)
+	txt.Color = colornames.White
+	for _, row := range v.rows {
+		fmt.Fprintf(txt, "0x%03X ", row.addr)
+		for _, cell := range row.cells {
+			txt.Color = colornames.White
+			if cell.edited {
+				txt.Color = colornames.Red
+			}
+			value := fmt.Sprintf("%02X", cell.value)
+			if cell.selected {
+				txt.Color = colornames.Yellow
+				if v.editing {
+					value = fmt.Sprintf("%-2s", v.input+"_")
+				}
+			}
+			fmt.Fprintf(txt, " %s", value)
+		}
+		fmt.Fprintln(txt)
+	}
+	txt.Color = colornames.White
+	fmt.Fprintln(txt)
+	if v.message != "" {
+		txt.Color = colornames.Red
+		fmt.Fprintln(txt, v.message)
+	} else if v.editing {
+		fmt.Fprintln(txt, "Type two hex digits, Backspace to cancel")
+	} else {
+		fmt.Fprintln(txt, "Arrows to move, Enter to edit, M to close")
+	}
+
+	// Place the text in the top left corner, over a dark panel
+	bounds := txt.Bounds()
+	width := bounds.W()*overlayScale + 2*overlayMargin
+	height := bounds.H()*overlayScale + 2*overlayMargin
+	top := win.Bounds().H()
+
+	imd := imdraw.New(nil)
+	imd.Color = pixel.RGB(0, 0, 0).Mul(pixel.Alpha(0.85))
+	imd.Push(pixel.V(0, top-height), pixel.V(width, top))
+	imd.Rectangle(0)
+	imd.Draw(win)
+
+	// Text is drawn downwards from the baseline of its first line
+	orig := pixel.V(overlayMargin, top-overlayMargin-overlayAtlas.Ascent()*overlayScale)
+	txt.Draw(win, pixel.IM.Scaled(pixel.ZV, overlayScale).Moved(orig))
+}
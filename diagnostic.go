@@ -0,0 +1,241 @@
+package chip8
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diagnosticCheck is a check of a quirk-sensitive instruction made by a
+// diagnostic ROM. Its code leaves a result in VA, which is compared with
+// the value expected for the quirks being diagnosed.
+// Checks must not change VB, VC or VD, which are used to report results.
+type diagnosticCheck struct {
+	name     string
+	code     string
+	data     string
+	expected func(q Quirks) byte
+}
+
+// diagnosticChecks are the checks made by a diagnostic ROM, in the order
+// their results are drawn. Labels must be unique across all checks.
+// KeyRelease is not checked, as it would need a key to be pressed.
+var diagnosticChecks = []diagnosticCheck{
+	{
+		name: "8XY1 resets VF",
+		code: `
+			LD VF, 5
+			LD V1, 1
+			LD V2, 2
+			OR V1, V2
+			LD VA, VF`,
+		expected: func(q Quirks) byte { return pick(q.VFReset, 0, 5) },
+	},
+	{
+		name: "8XY2 resets VF",
+		code: `
+			LD VF, 5
+			LD V1, 1
+			LD V2, 2
+			AND V1, V2
+			LD VA, VF`,
+		expected: func(q Quirks) byte { return pick(q.VFReset, 0, 5) },
+	},
+	{
+		name: "8XY3 resets VF",
+		code: `
+			LD VF, 5
+			LD V1, 1
+			LD V2, 2
+			XOR V1, V2
+			LD VA, VF`,
+		expected: func(q Quirks) byte { return pick(q.VFReset, 0, 5) },
+	},
+	{
+		name: "8XY6 shifts VX in place",
+		code: `
+			LD V1, 0x10
+			LD V2, 0x04
+			SHR V1, V2
+			LD VA, V1`,
+		expected: func(q Quirks) byte { return pick(q.Shift, 0x08, 0x02) },
+	},
+	{
+		name: "8XYE shifts VX in place",
+		code: `
+			LD V1, 0x10
+			LD V2, 0x04
+			SHL V1, V2
+			LD VA, V1`,
+		expected: func(q Quirks) byte { return pick(q.Shift, 0x20, 0x08) },
+	},
+	{
+		// The target may be at any address below 0xA00, so each register
+		// that could be used with the quirk offsets the jump
+		name: "BNNN jumps with VX",
+		code: `
+			LD V0, 0
+			LD V1, 4
+			LD V2, 4
+			LD V3, 4
+			LD V4, 4
+			LD V5, 4
+			LD V6, 4
+			LD V7, 4
+			LD V8, 4
+			LD V9, 4
+			JP V0, jump_target
+		jump_target:
+			LD VA, 1
+			JP jump_done
+			LD VA, 2
+		jump_done:`,
+		expected: func(q Quirks) byte { return pick(q.Jump, 2, 1) },
+	},
+	{
+		// The second read is from the byte after those written if I advanced
+		name: "FX55 advances I",
+		code: `
+			LD I, store_data
+			LD V0, 0xAA
+			LD V1, 0xCC
+			LD [I], V1
+			LD V0, [I]
+			LD VA, V0`,
+		data: `
+		store_data:
+			DB 0, 0, 0xBB`,
+		expected: func(q Quirks) byte { return pick(q.LoadStore, 0xBB, 0xAA) },
+	},
+	{
+		name: "FX65 advances I",
+		code: `
+			LD I, load_data
+			LD V1, [I]
+			LD V0, [I]
+			LD VA, V0`,
+		data: `
+		load_data:
+			DB 0x11, 0x22, 0x33`,
+		expected: func(q Quirks) byte { return pick(q.LoadStore, 0x33, 0x11) },
+	},
+	{
+		name: "FX1E sets VF on overflow",
+		code: `
+			LD VF, 5
+			LD I, 0xFFF
+			LD V1, 2
+			ADD I, V1
+			LD VA, VF`,
+		expected: func(q Quirks) byte { return pick(q.IndexOverflow, 1, 5) },
+	},
+	{
+		// A dot at the left edge collides with a sprite wrapped from the
+		// right edge. Both are drawn again to erase them.
+		name: "DXYN wraps sprites",
+		code: `
+			LD V1, 60
+			LD V2, 31
+			LD V3, 0
+			LD I, wrap_sprite
+			DRW V1, V2, 1
+			LD I, wrap_dot
+			DRW V3, V2, 1
+			LD VA, VF
+			DRW V3, V2, 1
+			LD I, wrap_sprite
+			DRW V1, V2, 1`,
+		data: `
+		wrap_sprite:
+			DB 0xFF
+		wrap_dot:
+			DB 0x80`,
+		expected: func(q Quirks) byte { return pick(q.DrawWrap, 1, 0) },
+	},
+	{
+		// Drawing four blank sprites takes at least three frames when each
+		// waits for the display, leaving the delay timer below 8. Otherwise
+		// it takes a few cycles, assuming at least 3 cycles per frame.
+		name: "DXYN waits for the display",
+		code: `
+			LD V1, 10
+			LD DT, V1
+			LD I, blank_sprite
+			DRW V0, V0, 1
+			DRW V0, V0, 1
+			DRW V0, V0, 1
+			DRW V0, V0, 1
+			LD VA, DT
+			LD V1, 8
+			SUB VA, V1
+			LD VA, VF`,
+		data: `
+		blank_sprite:
+			DB 0`,
+		expected: func(q Quirks) byte { return pick(q.DisplayWait, 0, 1) },
+	},
+}
+
+// diagnosticReport draws the result of a check as the next cell of the grid:
+// a solid block if VA matches the expected value in VB, or a cross if not.
+// VC and VD hold the position of the next cell.
+const diagnosticReport = `
+	report:
+		LD I, pass_glyph
+		SE VA, VB
+		LD I, fail_glyph
+		DRW VC, VD, 6
+		ADD VC, 8
+		SE VC, 64
+		RET
+		LD VC, 0
+		ADD VD, 8
+		RET
+
+	pass_glyph:
+		DB 0xFC, 0xFC, 0xFC, 0xFC, 0xFC, 0xFC
+	fail_glyph:
+		DB 0x84, 0x48, 0x30, 0x30, 0x48, 0x84`
+
+// pick returns ifSet if quirk is set, or ifUnset if not.
+func pick(quirk bool, ifSet, ifUnset byte) byte {
+	if quirk {
+		return ifSet
+	}
+	return ifUnset
+}
+
+// DiagnosticChecks returns the names of the checks made by a diagnostic ROM,
+// in the order their results are drawn.
+func DiagnosticChecks() []string {
+	names := make([]string, len(diagnosticChecks))
+	for i, check := range diagnosticChecks {
+		names[i] = check.name
+	}
+	return names
+}
+
+// DiagnosticROM generates a ROM that checks each quirk-sensitive instruction
+// behaves as expected for the quirks q.
+// The result of each check, as listed by DiagnosticChecks, is drawn in a
+// grid of cells from the top left of the display, 8 cells to a row. A solid
+// block shows the check passed and a cross that it failed, so running the
+// ROM on an interpreter with different quirks shows where they differ.
+// The ROM halts with a jump to itself once all checks have been drawn.
+func DiagnosticROM(q Quirks) ([]byte, error) {
+	var src strings.Builder
+	src.WriteString(`
+		CLS
+		LD VC, 0
+		LD VD, 0`)
+	for _, check := range diagnosticChecks {
+		fmt.Fprintf(&src, "\n\t; %s%s\n\t\tLD VB, %d\n\t\tCALL report", check.name, check.code, check.expected(q))
+	}
+	src.WriteString(`
+	halt:
+		JP halt`)
+	src.WriteString(diagnosticReport)
+	for _, check := range diagnosticChecks {
+		src.WriteString(check.data)
+	}
+	return Assemble(src.String())
+}
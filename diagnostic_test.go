@@ -0,0 +1,125 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+// runDiagnostic runs a diagnostic ROM until it halts at 300 cycles per
+// second, returning whether each check passed according to the display.
+func runDiagnostic(t *testing.T, rom []byte, opts ...Option) []bool {
+	t.Helper()
+	clock := &ManualClock{}
+	cpu, err := New(bytes.NewReader(rom), append(opts, WithClock(clock))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cpu.Close()
+	for i := 0; ; i++ {
+		if i == 10000 {
+			t.Fatal("diagnostic did not halt")
+		}
+		result, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Halted {
+			break
+		}
+		clock.Advance(timerPeriod / 5)
+	}
+
+	// Each cell is a solid 6x6 block if its check passed
+	gfx := cpu.GetGraphicsBuffer()
+	width, _ := cpu.Resolution()
+	passed := make([]bool, len(diagnosticChecks))
+	for i := range passed {
+		x0, y0 := i%8*8, i/8*8
+		passed[i] = true
+		for y := y0; y < y0+8; y++ {
+			for x := x0; x < x0+8; x++ {
+				on := x < x0+6 && y < y0+6
+				if (gfx[y*width+x] != 0) != on {
+					passed[i] = false
+				}
+			}
+		}
+	}
+	return passed
+}
+
+func TestDiagnosticROM(t *testing.T) {
+	for p := range profileNames {
+		t.Run(p.String(), func(t *testing.T) {
+			rom, err := DiagnosticROM(p.Quirks())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateROM(rom, WithProfile(p)); err != nil {
+				t.Fatal(err)
+			}
+			for i, passed := range runDiagnostic(t, rom, WithProfile(p)) {
+				if !passed {
+					t.Errorf("expected %q to pass", diagnosticChecks[i].name)
+				}
+			}
+		})
+	}
+}
+
+// TestDiagnosticROMDiffers confirms that checks fail where the machine's
+// quirks differ from those the ROM was generated for.
+func TestDiagnosticROMDiffers(t *testing.T) {
+	rom, err := DiagnosticROM(ProfileCosmacVIP.Quirks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := map[string]bool{
+		"8XY1 resets VF":             true,
+		"8XY2 resets VF":             true,
+		"8XY3 resets VF":             true,
+		"8XY6 shifts VX in place":    true,
+		"8XYE shifts VX in place":    true,
+		"BNNN jumps with VX":         true,
+		"FX55 advances I":            true,
+		"FX65 advances I":            true,
+		"DXYN waits for the display": true,
+	}
+	passed := runDiagnostic(t, rom, WithProfile(ProfileSuperChipLegacy))
+	for i, name := range DiagnosticChecks() {
+		if passed[i] == failing[name] {
+			t.Errorf("%q: expected passed to be %v, got %v", name, !failing[name], passed[i])
+		}
+	}
+}
+
+func TestDiagnosticROMEachQuirk(t *testing.T) {
+	var tests = []struct {
+		quirks Quirks
+		checks int
+	}{
+		{quirks: Quirks{VFReset: true}, checks: 3},
+		{quirks: Quirks{Shift: true}, checks: 2},
+		{quirks: Quirks{Jump: true}, checks: 1},
+		{quirks: Quirks{LoadStore: true}, checks: 2},
+		{quirks: Quirks{IndexOverflow: true}, checks: 1},
+		{quirks: Quirks{DrawWrap: true}, checks: 1},
+		{quirks: Quirks{DisplayWait: true}, checks: 1},
+	}
+	rom, err := DiagnosticROM(Quirks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		// Each quirk fails only the checks of the instructions it affects
+		var failed int
+		for _, passed := range runDiagnostic(t, rom, WithQuirks(test.quirks)) {
+			if !passed {
+				failed++
+			}
+		}
+		if failed != test.checks {
+			t.Errorf("%+v: expected %d checks to fail, got %d", test.quirks, test.checks, failed)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package chip8
+
+// Direction is a logical direction of movement, such as from a d-pad or
+// analog stick, that is translated to presses of keypad keys.
+type Direction int
+
+// Directions that may be passed to SetDirection.
+// DirectionNone releases all direction keys.
+const (
+	DirectionNone Direction = iota
+	DirectionN
+	DirectionNE
+	DirectionE
+	DirectionSE
+	DirectionS
+	DirectionSW
+	DirectionW
+	DirectionNW
+)
+
+// DirectionKeys maps the four cardinal directions to keypad keys.
+// Diagonals press the keys of both of their cardinal directions.
+type DirectionKeys struct {
+	Up, Down, Left, Right byte
+}
+
+// defaultDirectionKeys follows the common convention of 2, 8, 4 and 6
+// arranged as arrows on the keypad.
+var defaultDirectionKeys = DirectionKeys{Up: 0x2, Down: 0x8, Left: 0x4, Right: 0x6}
+
+// WithDirectionKeys configures the keys pressed by SetDirection.
+// By default, up, down, left and right are 2, 8, 4 and 6 respectively.
+// Only the low nibble of each key is used, as there are 16 keys.
+func WithDirectionKeys(keys DirectionKeys) Option {
+	return func(c *Chip8) {
+		c.directionKeys = DirectionKeys{
+			Up:    keys.Up & 0xF,
+			Down:  keys.Down & 0xF,
+			Left:  keys.Left & 0xF,
+			Right: keys.Right & 0xF,
+		}
+	}
+}
+
+// SetDirection presses the keys for dir, and releases the keys for any
+// other direction. Keys that are already in the right state are left
+// unchanged, so moving from N to NE only presses the key for right.
+func (c *Chip8) SetDirection(dir Direction) {
+	up := dir == DirectionN || dir == DirectionNE || dir == DirectionNW
+	down := dir == DirectionS || dir == DirectionSE || dir == DirectionSW
+	left := dir == DirectionW || dir == DirectionNW || dir == DirectionSW
+	right := dir == DirectionE || dir == DirectionNE || dir == DirectionSE
+
+	keys := c.directionKeys
+	var pressed [16]bool
+	pressed[keys.Up] = pressed[keys.Up] || up
+	pressed[keys.Down] = pressed[keys.Down] || down
+	pressed[keys.Left] = pressed[keys.Left] || left
+	pressed[keys.Right] = pressed[keys.Right] || right
+
+	// Release keys before pressing others, in a fixed order so that the key
+	// taken by FX0A is predictable
+	order := []byte{keys.Up, keys.Down, keys.Left, keys.Right}
+	for _, index := range order {
+		if !pressed[index] && c.key[index] != 0 {
+			c.SetKeyState(index, false)
+		}
+	}
+	for _, index := range order {
+		if pressed[index] && c.key[index] == 0 {
+			c.SetKeyState(index, true)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetDirection(t *testing.T) {
+	var tests = []struct {
+		name     string
+		opts     []Option
+		dir      Direction
+		expected []byte
+	}{
+		{
+			name:     "north east",
+			dir:      DirectionNE,
+			expected: []byte{0x2, 0x6},
+		},
+		{
+			name:     "south",
+			dir:      DirectionS,
+			expected: []byte{0x8},
+		},
+		{
+			name:     "none",
+			dir:      DirectionNone,
+			expected: nil,
+		},
+		{
+			name:     "custom keys",
+			opts:     []Option{WithDirectionKeys(DirectionKeys{Up: 0x5, Down: 0x8, Left: 0x7, Right: 0x9})},
+			dir:      DirectionNW,
+			expected: []byte{0x5, 0x7},
+		},
+		{
+			name:     "keys out of range",
+			opts:     []Option{WithDirectionKeys(DirectionKeys{Up: 0x12, Down: 0x18, Left: 0xF4, Right: 0x16})},
+			dir:      DirectionNW,
+			expected: []byte{0x2, 0x4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, err := New(bytes.NewReader(nil), test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Start from another direction, to check its keys are released
+			cpu.SetDirection(DirectionSW)
+			cpu.SetDirection(test.dir)
+
+			var expected [16]bool
+			for _, index := range test.expected {
+				expected[index] = true
+			}
+			if keys := cpu.GetKeys(); keys != expected {
+				t.Errorf("expected keys %v, got %v", expected, keys)
+			}
+		})
+	}
+}
+
+func TestSetDirectionWaitingForKey(t *testing.T) {
+	// Wait for a key in V3
+	cpu, err := New(bytes.NewReader(BuildROM(0xF30A)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatal(err)
+	}
+	cpu.SetDirection(DirectionSE)
+	expectRegister(t, cpu, 3, 0x8)
+	expectPC(t, cpu, 0x202)
+}
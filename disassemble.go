@@ -0,0 +1,311 @@
+package chip8
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Instruction describes a single opcode within a ROM.
+type Instruction struct {
+	Address uint16
+	Opcode  uint16
+
+	// OpcodeType and Pseudo match the values of a Result for this opcode.
+	// Unknown opcodes have an empty OpcodeType and are shown as data
+	// in the form "DW 0xNNNN". A trailing odd byte is shown as "DB 0xNN".
+	OpcodeType string
+	Pseudo     string
+}
+
+// Disassemble decodes the contents of a ROM without executing it.
+// Every two bytes of the ROM are decoded as an opcode, starting from
+// the address at which the ROM would be loaded.
+// Options such as WithLoadAddress and WithQuirks affect decoding as they
+// would execution.
+func Disassemble(rom io.Reader, opts ...Option) ([]Instruction, error) {
+	c, err := New(rom, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.disassemble(), nil
+}
+
+// ListProgram writes a labeled disassembly of the loaded ROM to w.
+// Each line contains the address, raw opcode, opcode type and pseudo code
+// for an instruction. Jump and subroutine targets are labeled.
+func (c *Chip8) ListProgram(w io.Writer) error {
+	instructions := c.disassemble()
+	names := labels(instructions)
+	for _, in := range instructions {
+		if name, ok := names[in.Address]; ok {
+			if _, err := fmt.Fprintf(w, "%s:\n", name); err != nil {
+				return err
+			}
+		}
+		pseudo := strings.Replace(in.Pseudo, "\n", " ", -1)
+		if name, ok := names[in.Opcode&0x0FFF]; ok && in.OpcodeType != "" {
+			switch in.Opcode & 0xF000 {
+			case 0x1000, 0x2000:
+				pseudo += " (" + name + ")"
+			}
+		}
+		_, err := fmt.Fprintf(w, "0x%03X  %04X  %-7s %s\n", in.Address, in.Opcode, in.OpcodeType, pseudo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disassemble decodes each opcode in the loaded ROM.
+func (c *Chip8) disassemble() []Instruction {
+	var instructions []Instruction
+	start := int(c.loadAddress)
+	end := start + c.romSize
+	for addr := start; addr < end; addr += 2 {
+		if addr+1 == end {
+			instructions = append(instructions, Instruction{
+				Address: uint16(addr),
+				Opcode:  uint16(c.memory[addr]),
+				Pseudo:  fmt.Sprintf("DB 0x%02X", c.memory[addr]),
+			})
+			break
+		}
+		instructions = append(instructions, c.decode(uint16(addr), c.opcodeAt(uint16(addr))))
+	}
+	return instructions
+}
+
+// opcodeAt returns the two byte opcode stored at addr.
+func (c *Chip8) opcodeAt(addr uint16) uint16 {
+	return uint16(c.memory[addr])<<8 | uint16(c.memory[addr+1])
+}
+
+// decode describes an opcode from its bits alone, without executing it or
+// reading any other state of the machine besides its quirks.
+func (c *Chip8) decode(addr, opcode uint16) Instruction {
+	in := Instruction{
+		Address: addr,
+		Opcode:  opcode,
+	}
+	opcodeType, p := c.describeOpcode(opcode)
+	in.OpcodeType, in.Pseudo = opcodeType, p.String()
+	if in.OpcodeType == "" {
+		in.Pseudo = fmt.Sprintf("DW 0x%04X", opcode)
+	}
+	return in
+}
+
+// pseudocode describes an opcode as the format and arguments of its Pseudo
+// text, so that it can be decoded on every cycle and only formatted when
+// Results are being fully populated.
+type pseudocode struct {
+	format string
+	args   [3]uint16
+	n      int
+}
+
+// pseudo returns the pseudocode formatted from format and up to 3 args.
+func pseudo(format string, args ...uint16) pseudocode {
+	p := pseudocode{format: format, n: len(args)}
+	copy(p.args[:], args)
+	return p
+}
+
+// String formats the pseudocode.
+func (p pseudocode) String() string {
+	if p.n == 0 {
+		return p.format
+	}
+	args := make([]interface{}, p.n)
+	for i := range args {
+		args[i] = p.args[i]
+	}
+	return fmt.Sprintf(p.format, args...)
+}
+
+// describeOpcode returns the OpcodeType and pseudocode of the Result that
+// executing opcode would produce, or an empty OpcodeType if it is unknown.
+// Opcode handlers describe their Results with it, so the disassembly of an
+// opcode always matches its execution.
+func (c *Chip8) describeOpcode(opcode uint16) (string, pseudocode) {
+	x := (opcode & 0x0F00) >> 8
+	y := (opcode & 0x00F0) >> 4
+	n := opcode & 0x000F
+	nn := opcode & 0x00FF
+	nnn := opcode & 0x0FFF
+
+	switch opcode & 0xF000 {
+	case 0x0000:
+		switch {
+		case opcode == 0x00E0:
+			return "0x00E0", pseudo("disp_clear()")
+		case opcode == 0x00EE:
+			return "0x00EE", pseudo("return;")
+		case opcode&0xFFF0 == 0x00C0:
+			return "0x00CN", pseudo("scroll_down(%d)", n)
+		case opcode == 0x00FB:
+			return "0x00FB", pseudo("scroll_right(4)")
+		case opcode == 0x00FC:
+			return "0x00FC", pseudo("scroll_left(4)")
+		case opcode == 0x00FE:
+			return "0x00FE", pseudo("low_res()")
+		case opcode == 0x00FF:
+			return "0x00FF", pseudo("high_res()")
+		case opcode&0xFFF0 == 0x00F0:
+			return "", pseudocode{}
+		}
+		return c.describeMachineCode(nnn)
+	case 0x1000:
+		return "0x1NNN", pseudo("goto 0x%X;", nnn)
+	case 0x2000:
+		return "0x2NNN", pseudo("*(0x%X)()", nnn)
+	case 0x3000:
+		return "0x3XNN", pseudo("if(V%d==0x%X)", x, nn)
+	case 0x4000:
+		return "0x4XNN", pseudo("if(V%d!=0x%X)", x, nn)
+	case 0x5000:
+		return "0x5XY0", pseudo("if(V%d==V%d)", x, y)
+	case 0x6000:
+		return "0x6XNN", pseudo("V%d = 0x%X", x, nn)
+	case 0x7000:
+		return "0x7XNN", pseudo("V%d += 0x%X", x, nn)
+	case 0x8000:
+		return c.describeArithmetic(x, y, n)
+	case 0x9000:
+		return "0x9XY0", pseudo("if(V%d!=V%d)", x, y)
+	case 0xA000:
+		return "0xANNN", pseudo("I = 0x%X", nnn)
+	case 0xB000:
+		if c.quirks.Jump {
+			return "0xBXNN", pseudo("PC=V%d+0x%X", x, nnn)
+		}
+		return "0xBNNN", pseudo("PC=V0+0x%X", nnn)
+	case 0xC000:
+		return "0xCXNN", pseudo("V%d=rand()&0x%X", x, nn)
+	case 0xD000:
+		return "0xDXYN", pseudo("draw(V%d,V%d,%d)", x, y, n)
+	case 0xE000:
+		switch nn {
+		case 0x009E:
+			return "0xEX9E", pseudo("if(key()==V%d)", x)
+		case 0x00A1:
+			return "0xEXA1", pseudo("if(key()!=V%d)", x)
+		}
+	case 0xF000:
+		return c.describeMisc(x, nn)
+	}
+	return "", pseudocode{}
+}
+
+// describeArithmetic describes the 8XYN opcodes.
+func (c *Chip8) describeArithmetic(x, y, n uint16) (string, pseudocode) {
+	switch n {
+	case 0x0:
+		return "0x8XY0", pseudo("V%d = V%d", x, y)
+	case 0x1:
+		if c.quirks.VFReset {
+			return "0x8XY1", pseudo("V%d |= V%d; VF = 0", x, y)
+		}
+		return "0x8XY1", pseudo("V%d |= V%d", x, y)
+	case 0x2:
+		if c.quirks.VFReset {
+			return "0x8XY2", pseudo("V%d &= V%d; VF = 0", x, y)
+		}
+		return "0x8XY2", pseudo("V%d &= V%d", x, y)
+	case 0x3:
+		if c.quirks.VFReset {
+			return "0x8XY3", pseudo("V%d ^= V%d; VF = 0", x, y)
+		}
+		return "0x8XY3", pseudo("V%d ^= V%d", x, y)
+	case 0x4:
+		return "0x8XY4", pseudo("V%d += V%d", x, y)
+	case 0x5:
+		return "0x8XY5", pseudo("V%d -= V%d", x, y)
+	case 0x6:
+		if c.quirks.Shift {
+			return "0x8XY6", pseudo("V%d>>=1", x)
+		}
+		return "0x8XY6", pseudo("V%d=V%d=V%d>>1", x, y, y)
+	case 0x7:
+		return "0x8XY7", pseudo("V%d=V%d-V%d", x, y, x)
+	case 0xE:
+		if c.quirks.Shift {
+			return "0x8XYE", pseudo("V%d<<=1", x)
+		}
+		return "0x8XYE", pseudo("V%d=V%d=V%d<<1", x, y, y)
+	}
+	return "", pseudocode{}
+}
+
+// describeMisc describes the FXNN opcodes.
+func (c *Chip8) describeMisc(x, nn uint16) (string, pseudocode) {
+	// Only V0-V7 can be saved to the RPL flags
+	rpl := x
+	if rpl > 7 {
+		rpl = 7
+	}
+	switch nn {
+	case 0x01:
+		if x > 0x3 {
+			return "", pseudocode{}
+		}
+		return "0xFN01", pseudo("plane(%d)", x)
+	case 0x07:
+		return "0xFX07", pseudo("Vx = get_delay()")
+	case 0x0A:
+		return "0xFX0A", pseudo("V%d = get_key()", x)
+	case 0x15:
+		return "0xFX15", pseudo("delay_timer(V%d)", x)
+	case 0x18:
+		return "0xFX18", pseudo("sound_timer(V%d)", x)
+	case 0x1E:
+		if c.quirks.IndexOverflow {
+			return "0xFX1E", pseudo("I += V%d; VF = overflow", x)
+		}
+		return "0xFX1E", pseudo("I += V%d", x)
+	case 0x29:
+		return "0xFX29", pseudo("I=sprite_addr[V%d]", x)
+	case 0x30:
+		return "0xFX30", pseudo("I=big_sprite_addr[V%d]", x)
+	case 0x33:
+		return "0xFX33", pseudo("set_BCD(V%d);\n*(I + 0) = BCD(3)\n*(I + 1) = BCD(2)\n*(I + 2) = BCD(1)", x)
+	case 0x55:
+		if c.quirks.LoadStore {
+			return "0xFX55", pseudo("reg_dump(V%d, &I); I += %d", x, x+1)
+		}
+		return "0xFX55", pseudo("reg_dump(V%d, &I)", x)
+	case 0x65:
+		if c.quirks.LoadStore {
+			return "0xFX65", pseudo("reg_load(V%d,&I); I += %d", x, x+1)
+		}
+		return "0xFX65", pseudo("reg_load(V%d,&I)", x)
+	case 0x75:
+		return "0xFX75", pseudo("rpl_save(V%d)", rpl)
+	case 0x85:
+		return "0xFX85", pseudo("rpl_load(V%d)", rpl)
+	}
+	return "", pseudocode{}
+}
+
+// labels generates names for the targets of jumps and subroutine calls.
+func labels(instructions []Instruction) map[uint16]string {
+	names := make(map[uint16]string)
+	for _, in := range instructions {
+		if in.OpcodeType == "" {
+			continue
+		}
+		target := in.Opcode & 0x0FFF
+		switch in.Opcode & 0xF000 {
+		case 0x1000:
+			if _, ok := names[target]; !ok {
+				names[target] = fmt.Sprintf("label_%03X", target)
+			}
+		case 0x2000:
+			names[target] = fmt.Sprintf("sub_%03X", target)
+		}
+	}
+	return names
+}
@@ -0,0 +1,155 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestListProgram(t *testing.T) {
+	cpu, err := New(bytes.NewReader([]byte{
+		0x22, 0x06, // 0x200: call 0x206
+		0x12, 0x02, // 0x202: goto 0x202
+		0xFF, 0xFF, // 0x204: data
+		0x60, 0x2A, // 0x206: V0 = 0x2A
+		0x00, 0xEE, // 0x208: return
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := cpu.ListProgram(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listing := out.String()
+
+	for _, expected := range []string{
+		"0x200  2206  0x2NNN  *(0x206)() (sub_206)\n",
+		"label_202:\n0x202  1202  0x1NNN  goto 0x202; (label_202)\n",
+		"0x204  FFFF          DW 0xFFFF\n",
+		"sub_206:\n0x206  602A  0x6XNN  V0 = 0x2A\n",
+		"0x208  00EE  0x00EE  return;\n",
+	} {
+		if !strings.Contains(listing, expected) {
+			t.Errorf("expected listing to contain %q, got:\n%s", expected, listing)
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	// V0 = 0x2A; goto 0x600 + V0; data
+	rom := BuildROM(0x602A, 0xB600, 0xFFFF)
+	expected := []Instruction{
+		{Address: 0x600, Opcode: 0x602A, OpcodeType: "0x6XNN", Pseudo: "V0 = 0x2A"},
+		{Address: 0x602, Opcode: 0xB600, OpcodeType: "0xBXNN", Pseudo: "PC=V6+0x600"},
+		{Address: 0x604, Opcode: 0xFFFF, Pseudo: "DW 0xFFFF"},
+	}
+
+	instructions, err := Disassemble(bytes.NewReader(rom), WithLoadAddress(0x600), WithQuirks(Quirks{Jump: true}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(instructions) != len(expected) {
+		t.Fatalf("expected %d instructions, got %+v", len(expected), instructions)
+	}
+	for i := range expected {
+		if instructions[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], instructions[i])
+		}
+	}
+}
+
+func TestDisassembleUnknownOpcodes(t *testing.T) {
+	rom := []byte{
+		0x00, 0xE0, // disp_clear()
+		0x8A, 0xBF, // unknown arithmetic
+		0xE0, 0xFF, // unknown key opcode
+		0xF0, 0xFF, // unknown misc opcode
+		0x12, 0x00, // goto 0x200
+		0xAB, // trailing byte
+	}
+	expected := []Instruction{
+		{Address: 0x200, Opcode: 0x00E0, OpcodeType: "0x00E0", Pseudo: "disp_clear()"},
+		{Address: 0x202, Opcode: 0x8ABF, Pseudo: "DW 0x8ABF"},
+		{Address: 0x204, Opcode: 0xE0FF, Pseudo: "DW 0xE0FF"},
+		{Address: 0x206, Opcode: 0xF0FF, Pseudo: "DW 0xF0FF"},
+		{Address: 0x208, Opcode: 0x1200, OpcodeType: "0x1NNN", Pseudo: "goto 0x200;"},
+		{Address: 0x20A, Opcode: 0x00AB, Pseudo: "DB 0xAB"},
+	}
+
+	instructions, err := Disassemble(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(instructions) != len(expected) {
+		t.Fatalf("expected %d instructions, got %+v", len(expected), instructions)
+	}
+	for i := range expected {
+		if instructions[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], instructions[i])
+		}
+	}
+}
+
+func TestDisassembleIgnoresRegisters(t *testing.T) {
+	// V0 = 0xFF would index past the keys if EX9E were executed, and
+	// I = 0xFFF would leave no room for FX55 to store V0-V5
+	rom := BuildROM(0x60FF, 0xE09E, 0xAFFF, 0xF555)
+	expected := []Instruction{
+		{Address: 0x200, Opcode: 0x60FF, OpcodeType: "0x6XNN", Pseudo: "V0 = 0xFF"},
+		{Address: 0x202, Opcode: 0xE09E, OpcodeType: "0xEX9E", Pseudo: "if(key()==V0)"},
+		{Address: 0x204, Opcode: 0xAFFF, OpcodeType: "0xANNN", Pseudo: "I = 0xFFF"},
+		{Address: 0x206, Opcode: 0xF555, OpcodeType: "0xFX55", Pseudo: "reg_dump(V5, &I)"},
+	}
+
+	instructions, err := Disassemble(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(instructions) != len(expected) {
+		t.Fatalf("expected %d instructions, got %+v", len(expected), instructions)
+	}
+	for i := range expected {
+		if instructions[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], instructions[i])
+		}
+	}
+}
+
+// TestDecodeMatchesHandlers checks that every opcode is described as its
+// handler would describe it when executed, and that the opcodes handlers
+// reject are decoded as unknown.
+func TestDecodeMatchesHandlers(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithQuirks(Quirks{Shift: true, LoadStore: true, Jump: true, IndexOverflow: true, VFReset: true})},
+		{WithMachineCodeBehavior(MachineCodeError)},
+	} {
+		cpu := newChip8(append(opts, WithFullResults()))
+		for opcode := 0; opcode <= 0xFFFF; opcode++ {
+			cpu.V = [16]byte{}
+			cpu.I = 0x300
+			cpu.pc = 0x200
+			cpu.sp = 1
+			cpu.vblank = true
+
+			result, err := cpu.opcodes[uint16(opcode)&0xF000](uint16(opcode))
+			in := cpu.decode(0x200, uint16(opcode))
+			opcodeType, pseudo := in.OpcodeType, in.Pseudo
+			if errors.Is(err, ErrUnknownOpcode) {
+				if opcodeType != "" {
+					t.Errorf("0x%04X: expected unknown opcode, got %q", opcode, opcodeType)
+				}
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			if opcodeType != result.OpcodeType || pseudo != result.Pseudo {
+				t.Errorf("0x%04X: expected %q %q, got %q %q", opcode, result.OpcodeType, result.Pseudo, opcodeType, pseudo)
+			}
+		}
+	}
+}
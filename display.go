@@ -0,0 +1,108 @@
+package chip8
+
+import "bytes"
+
+// Region is a rectangular area of the display, in pixels.
+type Region struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Empty returns true iff the region contains no pixels.
+func (r Region) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// union returns the smallest region containing both r and o.
+func (r Region) union(o Region) Region {
+	if r.Empty() {
+		return o
+	}
+	if o.Empty() {
+		return r
+	}
+	x0, y0 := r.X, r.Y
+	x1, y1 := r.X+r.Width, r.Y+r.Height
+	if o.X < x0 {
+		x0 = o.X
+	}
+	if o.Y < y0 {
+		y0 = o.Y
+	}
+	if o.X+o.Width > x1 {
+		x1 = o.X + o.Width
+	}
+	if o.Y+o.Height > y1 {
+		y1 = o.Y + o.Height
+	}
+	return Region{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+// markDirty records that pixels in r may have changed.
+func (c *Chip8) markDirty(r Region) {
+	c.dirty = c.dirty.union(r)
+}
+
+// markAllDirty records that any pixel on the display may have changed.
+func (c *Chip8) markAllDirty() {
+	c.dirty = Region{Width: c.width, Height: c.height}
+}
+
+// BeginFrame marks the start of a frame for a frontend that only redraws the
+// parts of the display that change. The display is recorded for comparison by
+// EndFrame, and the dirty region is cleared.
+func (c *Chip8) BeginFrame() {
+	c.frameStart = append(c.frameStart[:0], c.gfx...)
+	c.frameStart = append(c.frameStart, c.gfx2...)
+	c.dirty = Region{}
+}
+
+// EndFrame returns the region of the display drawn to since BeginFrame,
+// and whether any pixels are different as a result.
+// Pixels may be drawn to without changing, for example when a sprite is drawn
+// and then erased within the same frame.
+func (c *Chip8) EndFrame() (dirty Region, changed bool) {
+	n := len(c.gfx)
+	changed = len(c.frameStart) != 2*n ||
+		!bytes.Equal(c.frameStart[:n], c.gfx) ||
+		!bytes.Equal(c.frameStart[n:], c.gfx2)
+	return c.dirty, changed
+}
+
+// scrollDown moves the selected planes down by n rows, clearing the rows vacated at the top.
+func (c *Chip8) scrollDown(n int) {
+	if n > c.height {
+		n = c.height
+	}
+	for _, plane := range c.selectedPlanes() {
+		copy(plane[n*c.width:], plane[:(c.height-n)*c.width])
+		for i := 0; i < n*c.width; i++ {
+			plane[i] = 0
+		}
+	}
+	c.markAllDirty()
+	c.drawFlag = true
+}
+
+// scrollHorizontal moves each row of the selected planes by n columns, to the right
+// for positive n and to the left for negative n, clearing vacated columns.
+func (c *Chip8) scrollHorizontal(n int) {
+	for _, plane := range c.selectedPlanes() {
+		for y := 0; y < c.height; y++ {
+			row := plane[y*c.width : (y+1)*c.width]
+			if n > 0 {
+				copy(row[n:], row[:c.width-n])
+				for x := 0; x < n; x++ {
+					row[x] = 0
+				}
+			} else {
+				copy(row, row[-n:])
+				for x := c.width + n; x < c.width; x++ {
+					row[x] = 0
+				}
+			}
+		}
+	}
+	c.markAllDirty()
+	c.drawFlag = true
+}
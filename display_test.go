@@ -0,0 +1,59 @@
+package chip8
+
+import "testing"
+
+func TestFrameDirtyRegion(t *testing.T) {
+	cpu := initCPU()
+	cpu.memory[0x300] = 0xFF
+	cpu.memory[0x301] = 0x81
+	draw := func() {
+		t.Helper()
+		cpu.I = 0x300
+		cpu.V[0], cpu.V[1] = 10, 5
+		if _, err := cpu.opcode0xD000(0xD012); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	cpu.BeginFrame()
+	if dirty, changed := cpu.EndFrame(); !dirty.Empty() || changed {
+		t.Errorf("expected an unchanged frame, got %+v, %v", dirty, changed)
+	}
+
+	// Frame 1 draws the sprite
+	cpu.BeginFrame()
+	draw()
+	dirty, changed := cpu.EndFrame()
+	if expected := (Region{X: 10, Y: 5, Width: 8, Height: 2}); dirty != expected || !changed {
+		t.Errorf("expected %+v and changed, got %+v, %v", expected, dirty, changed)
+	}
+
+	// Frame 2 draws a single pixel elsewhere, starting from a clean region
+	cpu.BeginFrame()
+	cpu.V[0], cpu.V[1] = 40, 20
+	if err := cpu.LoadSprite(0x302, []byte{0x80}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.opcode0xD000(0xD011); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dirty, changed = cpu.EndFrame()
+	if expected := (Region{X: 40, Y: 20, Width: 1, Height: 1}); dirty != expected || !changed {
+		t.Errorf("expected %+v and changed, got %+v, %v", expected, dirty, changed)
+	}
+
+	// Frame 3 draws and erases a sprite, so nothing changes
+	cpu.BeginFrame()
+	draw()
+	draw()
+	dirty, changed = cpu.EndFrame()
+	if dirty.Empty() || changed {
+		t.Errorf("expected a dirty but unchanged frame, got %+v, %v", dirty, changed)
+	}
+
+	// Reading the draw flag also clears the region
+	cpu.DrawFlag()
+	if dirty, _ := cpu.EndFrame(); !dirty.Empty() {
+		t.Errorf("expected the region to be cleared, got %+v", dirty)
+	}
+}
@@ -0,0 +1,42 @@
+package chip8
+
+// EndOfProgramBehavior determines what happens when the program counter
+// runs past the end of the loaded ROM.
+type EndOfProgramBehavior int
+
+const (
+	// EndOfProgramLoop continues executing whatever follows the ROM in memory,
+	// usually zeroes, which are skipped as calls to machine code.
+	// This is the default.
+	EndOfProgramLoop EndOfProgramBehavior = iota
+	// EndOfProgramHalt stops execution without an error. Every subsequent
+	// cycle returns a Result with Halted set, along with an error wrapping
+	// ErrHalted.
+	EndOfProgramHalt
+	// EndOfProgramError causes EmulateCycle to return an error wrapping ErrEndOfProgram.
+	EndOfProgramError
+)
+
+// WithEndOfProgramBehavior configures what happens when execution runs past the end of the ROM.
+func WithEndOfProgramBehavior(b EndOfProgramBehavior) Option {
+	return func(c *Chip8) {
+		c.endOfProgram = b
+	}
+}
+
+// checkEndOfProgram returns true if the program should halt at the current
+// program counter, or an error if running past the end of the ROM is an error
+// or the program has already halted.
+func (c *Chip8) checkEndOfProgram() (bool, error) {
+	if c.halted {
+		return true, ErrHalted
+	}
+	if c.endOfProgram == EndOfProgramLoop || c.pc < c.loadAddress+uint16(c.romSize) {
+		return false, nil
+	}
+	if c.endOfProgram == EndOfProgramError {
+		return false, ErrEndOfProgram
+	}
+	c.halted = true
+	return true, nil
+}
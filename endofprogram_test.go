@@ -0,0 +1,115 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEndOfProgram(t *testing.T) {
+	// V0 = 1; V1 = 2; then off the end of the ROM
+	rom := BuildROM(0x6001, 0x6102)
+
+	t.Run("loop", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(rom), WithEndOfProgramBehavior(EndOfProgramLoop))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error on cycle %d: %v", i, err)
+			}
+		}
+		// Zeroed memory is executed as usual
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectOpcodeType(t, r, "0x0NNN")
+		expectPC(t, cpu, 0x206)
+	})
+
+	t.Run("halt", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(rom), WithEndOfProgramBehavior(EndOfProgramHalt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			r, err := cpu.EmulateCycle()
+			if err != nil {
+				t.Fatalf("unexpected error on cycle %d: %v", i, err)
+			}
+			if r.Halted {
+				t.Fatalf("unexpected halt on cycle %d", i)
+			}
+		}
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !r.Halted {
+			t.Errorf("expected machine to be halted")
+		}
+
+		// Further cycles report that the machine has already halted
+		for i := 0; i < 3; i++ {
+			r, err := cpu.EmulateCycle()
+			if !errors.Is(err, ErrHalted) {
+				t.Errorf("expected ErrHalted, got %v", err)
+			}
+			if !r.Halted {
+				t.Errorf("expected machine to be halted")
+			}
+			expectPC(t, cpu, 0x204)
+		}
+		expectRegister(t, cpu, 1, 2)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(rom), WithEndOfProgramBehavior(EndOfProgramError))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error on cycle %d: %v", i, err)
+			}
+		}
+		_, err = cpu.EmulateCycle()
+		if !errors.Is(err, ErrEndOfProgram) {
+			t.Fatalf("expected end of program error, got %v", err)
+		}
+		var chipErr *Error
+		if errors.As(err, &chipErr) && chipErr.PC != 0x204 {
+			t.Errorf("expected error at 0x204, got 0x%03X", chipErr.PC)
+		}
+	})
+}
+
+func TestSelfJumpHalts(t *testing.T) {
+	// V0 = 1; goto 0x204; goto 0x204
+	cpu, err := New(bytes.NewReader(BuildROM(0x6001, 0x1204, 0x1204)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error on cycle %d: %v", i, err)
+		}
+		if r.Halted {
+			t.Errorf("unexpected halt on cycle %d", i)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !r.Halted {
+			t.Errorf("expected a jump to itself to halt")
+		}
+		expectOpcodeType(t, r, "0x1NNN")
+		expectPC(t, cpu, 0x204)
+	}
+}
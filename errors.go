@@ -0,0 +1,88 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Classes of error that may be returned by a Chip8.
+// Errors returned from EmulateCycle wrap one of these values in an *Error,
+// so they should be tested for using errors.Is.
+var (
+	// ErrUnknownOpcode indicates that the ROM contains an opcode that could not be decoded.
+	ErrUnknownOpcode = errors.New("unknown opcode")
+	// ErrStackOverflow indicates a subroutine call when all stack levels are in use.
+	ErrStackOverflow = errors.New("stack overflow")
+	// ErrStackUnderflow indicates a return from a subroutine with an empty stack.
+	ErrStackUnderflow = errors.New("stack underflow")
+	// ErrMemoryOutOfRange indicates an access outside of the 4K address space.
+	ErrMemoryOutOfRange = errors.New("memory access out of range")
+	// ErrPCOutOfRange indicates that the program counter left the 4K address space.
+	ErrPCOutOfRange = errors.New("pc out of range")
+	// ErrClosed indicates that the machine has been closed with Close.
+	ErrClosed = errors.New("machine closed")
+	// ErrHalted indicates a cycle of a machine that has already halted at the
+	// end of its program, and will not execute further opcodes.
+	ErrHalted = errors.New("machine halted")
+	// ErrLimitExceeded indicates that a configured resource limit was reached,
+	// such as the number of cycles allowed by WithWatchdog.
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// ErrStateVersion indicates saved state in a format this version cannot read.
+	ErrStateVersion = errors.New("unsupported state version")
+	// ErrSaveStateFormat indicates data that is not a valid save state.
+	ErrSaveStateFormat = errors.New("invalid save state")
+	// ErrStalled indicates that the program counter has stopped advancing.
+	// It wraps ErrLimitExceeded.
+	ErrStalled = fmt.Errorf("program counter stalled: %w", ErrLimitExceeded)
+	// ErrEndOfProgram indicates that execution ran past the end of the loaded ROM.
+	ErrEndOfProgram = errors.New("end of program")
+	// ErrROMTooLarge indicates a ROM that does not fit into memory.
+	ErrROMTooLarge = errors.New("rom too large")
+	// ErrNoHistory indicates that there are no earlier cycles to step back to.
+	ErrNoHistory = errors.New("no history")
+	// ErrBreakpoint indicates that execution stopped at a breakpoint.
+	ErrBreakpoint = errors.New("breakpoint")
+)
+
+// Error provides the context in which an error occurred during emulation.
+// Err will be one of the error classes above, possibly wrapped with
+// further detail.
+type Error struct {
+	Err error
+
+	// Program counter and opcode at the time of the error
+	PC     uint16
+	Opcode uint16
+	// Number of cycles completed before the error
+	Cycle uint64
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: opcode 0x%04X at 0x%03X (cycle %d)", e.Err, e.Opcode, e.PC, e.Cycle)
+}
+
+// Unwrap returns the underlying error class.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsROMFault returns true iff err was caused by the ROM being executed,
+// rather than a problem with the emulator or how it is being used.
+func IsROMFault(err error) bool {
+	return errors.Is(err, ErrUnknownOpcode) ||
+		errors.Is(err, ErrStackOverflow) ||
+		errors.Is(err, ErrStackUnderflow) ||
+		errors.Is(err, ErrMemoryOutOfRange) ||
+		errors.Is(err, ErrPCOutOfRange) ||
+		errors.Is(err, ErrEndOfProgram)
+}
+
+// wrapError adds the current machine context to err.
+func (c *Chip8) wrapError(err error, pc, opcode uint16) error {
+	return &Error{
+		Err:    err,
+		PC:     pc,
+		Opcode: opcode,
+		Cycle:  c.cycles,
+	}
+}
@@ -0,0 +1,202 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorClasses(t *testing.T) {
+	var classes = []struct {
+		err      error
+		romFault bool
+	}{
+		{err: ErrUnknownOpcode, romFault: true},
+		{err: ErrStackOverflow, romFault: true},
+		{err: ErrStackUnderflow, romFault: true},
+		{err: ErrMemoryOutOfRange, romFault: true},
+		{err: ErrPCOutOfRange, romFault: true},
+		{err: ErrClosed},
+		{err: ErrHalted},
+		{err: ErrLimitExceeded},
+		{err: ErrStateVersion},
+		{err: ErrSaveStateFormat},
+		{err: ErrStalled},
+		{err: ErrEndOfProgram, romFault: true},
+		{err: ErrROMTooLarge},
+		{err: ErrNoHistory},
+		{err: ErrBreakpoint},
+	}
+	for _, class := range classes {
+		t.Run(class.err.Error(), func(t *testing.T) {
+			var err error = &Error{Err: class.err, PC: 0x234, Opcode: 0xABCD, Cycle: 5}
+			if !errors.Is(err, class.err) {
+				t.Errorf("expected error to be %v", class.err)
+			}
+			for _, other := range classes {
+				// Stalling is the one limit that has its own class
+				if class.err == ErrStalled && other.err == ErrLimitExceeded {
+					continue
+				}
+				if other.err != class.err && errors.Is(err, other.err) {
+					t.Errorf("did not expect error to be %v", other.err)
+				}
+			}
+			var chipErr *Error
+			if !errors.As(err, &chipErr) {
+				t.Fatalf("expected error to be an *Error")
+			}
+			if chipErr.PC != 0x234 || chipErr.Opcode != 0xABCD || chipErr.Cycle != 5 {
+				t.Errorf("unexpected context: %+v", chipErr)
+			}
+			if IsROMFault(err) != class.romFault {
+				t.Errorf("expected IsROMFault to be %v", class.romFault)
+			}
+		})
+	}
+}
+
+func TestEmulateCycleUnknownOpcode(t *testing.T) {
+	// 0x6001 is valid, 0x8008 is not
+	cpu, err := New(bytes.NewReader([]byte{0x60, 0x01, 0x80, 0x08}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = cpu.EmulateCycle()
+	if !errors.Is(err, ErrUnknownOpcode) {
+		t.Fatalf("expected unknown opcode, got %v", err)
+	}
+	var chipErr *Error
+	if !errors.As(err, &chipErr) {
+		t.Fatalf("expected error to be an *Error")
+	}
+	if chipErr.Opcode != 0x8008 {
+		t.Errorf("expected opcode 0x8008, got 0x%X", chipErr.Opcode)
+	}
+	if chipErr.PC != 0x202 {
+		t.Errorf("expected PC 0x202, got 0x%X", chipErr.PC)
+	}
+	if chipErr.Cycle != 1 {
+		t.Errorf("expected cycle 1, got %d", chipErr.Cycle)
+	}
+}
+
+func TestUnknownOpcodes(t *testing.T) {
+	for _, opcode := range []uint16{0x00FD, 0x800F, 0xE1FF, 0xF0FF} {
+		t.Run(fmt.Sprintf("0x%04X", opcode), func(t *testing.T) {
+			// V0 = 1; unknown
+			cpu, err := New(bytes.NewReader(BuildROM(0x6001, opcode)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = cpu.Step(2)
+			if !errors.Is(err, ErrUnknownOpcode) {
+				t.Fatalf("expected unknown opcode, got %v", err)
+			}
+			var chipErr *Error
+			if !errors.As(err, &chipErr) {
+				t.Fatalf("expected error to be an *Error")
+			}
+			if chipErr.Opcode != opcode || chipErr.PC != 0x202 {
+				t.Errorf("expected opcode 0x%04X at 0x202, got 0x%04X at 0x%03X", opcode, chipErr.Opcode, chipErr.PC)
+			}
+			expected := fmt.Sprintf("unknown opcode: opcode 0x%04X at 0x202 (cycle 1)", opcode)
+			if err.Error() != expected {
+				t.Errorf("expected message %q, got %q", expected, err.Error())
+			}
+			// The program counter does not move past the unknown opcode
+			expectPC(t, cpu, 0x202)
+		})
+	}
+}
+
+func TestEmulateCycleClosed(t *testing.T) {
+	cpu := initCPU()
+	cpu.Close()
+	if _, ok := <-cpu.Beep(); ok {
+		t.Errorf("expected beep channel to be closed")
+	}
+	_, err := cpu.EmulateCycle()
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("expected closed error, got %v", err)
+	}
+}
+
+func TestEmulateCyclePCOutOfRange(t *testing.T) {
+	cpu := initCPU()
+	cpu.pc = 0xFFF
+	cpu.V[2] = 0x34
+
+	r, err := cpu.EmulateCycle()
+	if !errors.Is(err, ErrPCOutOfRange) {
+		t.Fatalf("expected pc out of range, got %v", err)
+	}
+	if err.Error() != "pc out of range: 0xFFF: opcode 0x0000 at 0xFFF (cycle 0)" {
+		t.Errorf("unexpected error message: %q", err)
+	}
+	if r.Before.PC != 0xFFF || r.Before.V[2] != 0x34 {
+		t.Errorf("expected result to describe the current state, got %+v", r.Before)
+	}
+	expectPC(t, cpu, 0xFFF)
+
+	// The last complete opcode in memory can still be executed
+	cpu.pc = 0xFFE
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestJumpOutOfRange(t *testing.T) {
+	var tests = []struct {
+		name   string
+		opcode uint16
+		v0     byte
+	}{
+		{name: "jump", opcode: 0x1FFF},
+		{name: "call", opcode: 0x2FFF},
+		{name: "jump with offset", opcode: 0xBF80, v0: 0x80},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// V0 = v0; jump
+			cpu, err := New(bytes.NewReader(BuildROM(0x6000|uint16(test.v0), test.opcode)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = cpu.Step(2)
+			if !errors.Is(err, ErrPCOutOfRange) {
+				t.Fatalf("expected pc out of range, got %v", err)
+			}
+			// The jump is identified as the cause
+			var chipErr *Error
+			if !errors.As(err, &chipErr) || chipErr.PC != 0x202 || chipErr.Opcode != test.opcode {
+				t.Errorf("expected error for opcode 0x%04X at 0x202, got %v", test.opcode, err)
+			}
+			expectPC(t, cpu, 0x202)
+			if depth := cpu.StackDepth(); depth != 0 {
+				t.Errorf("expected nothing to be pushed to the stack, got depth %d", depth)
+			}
+		})
+	}
+}
+
+func TestExecutePastEndOfMemory(t *testing.T) {
+	// Zeroed memory is executed up to the last opcode, which runs off the end
+	cpu, err := New(bytes.NewReader(nil), WithLoadAddress(0xFFA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := cpu.Step(10)
+	if !errors.Is(err, ErrPCOutOfRange) {
+		t.Fatalf("expected pc out of range, got %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("expected 3 cycles before the error, got %d", len(results))
+	}
+	expectPC(t, cpu, 0x1000)
+}
@@ -0,0 +1,48 @@
+package chip8
+
+// flickerDetector records how often pixels drawn by DXYN are erased again
+// within a frame of being drawn, which is seen as flicker.
+type flickerDetector struct {
+	// Frame number (plus one) at which each pixel was last turned on
+	lastOn []uint64
+
+	pixelsOn uint64
+	flickers uint64
+}
+
+// WithFlickerDetection enables measurement of sprite flicker, reported by FlickerScore.
+func WithFlickerDetection() Option {
+	return func(c *Chip8) {
+		c.flicker = &flickerDetector{}
+		c.flicker.resize(len(c.gfx))
+	}
+}
+
+// FlickerScore returns the fraction of pixels turned on by sprite draws
+// that were turned off again within the following frame.
+// A high score suggests a ROM would benefit from the display wait quirk.
+// The score is always zero unless the machine was created with WithFlickerDetection.
+func (c *Chip8) FlickerScore() float64 {
+	if c.flicker == nil || c.flicker.pixelsOn == 0 {
+		return 0
+	}
+	return float64(c.flicker.flickers) / float64(c.flicker.pixelsOn)
+}
+
+// resize resets the detector for a display of the given number of pixels.
+func (f *flickerDetector) resize(pixels int) {
+	f.lastOn = make([]uint64, pixels)
+}
+
+// toggled records a pixel being flipped by a sprite draw during frame.
+func (f *flickerDetector) toggled(index int, on bool, frame uint64) {
+	if on {
+		f.pixelsOn++
+		f.lastOn[index] = frame + 1
+		return
+	}
+	if f.lastOn[index] != 0 && frame+1-f.lastOn[index] <= 1 {
+		f.flickers++
+	}
+	f.lastOn[index] = 0
+}
@@ -0,0 +1,72 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFlickerScore(t *testing.T) {
+	var tests = []struct {
+		name string
+		// Cycles to run between each frame
+		cyclesPerFrame int
+		expectHigh     bool
+	}{
+		{
+			name:           "toggling",
+			cyclesPerFrame: 1,
+			expectHigh:     true,
+		},
+		{
+			name:           "stable",
+			cyclesPerFrame: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// I = 0x50; draw(V0,V0,5); goto 0x202
+			// Stop time so ticks are driven by the test
+			cpu, err := New(bytes.NewReader(BuildROM(0xA050, 0xD005, 0x1202)), WithFlickerDetection(), WithClock(&ManualClock{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			run := func(cycles int) {
+				for i := 0; i < cycles; i++ {
+					if _, err := cpu.EmulateCycle(); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+			}
+
+			// Draw the sprite once
+			run(2)
+			for frame := 0; frame < 20; frame++ {
+				cpu.updateTimers()
+				// Jump and redraw, toggling the sprite
+				run(test.cyclesPerFrame * 2)
+			}
+
+			score := cpu.FlickerScore()
+			if test.expectHigh && score < 0.9 {
+				t.Errorf("expected a high score, got %v", score)
+			}
+			if !test.expectHigh && score != 0 {
+				t.Errorf("expected a score of 0, got %v", score)
+			}
+		})
+	}
+}
+
+func TestFlickerScoreDisabled(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[0] = 0
+	for i := 0; i < 4; i++ {
+		if _, err := cpu.opcode0xD000(0xD005); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if score := cpu.FlickerScore(); score != 0 {
+		t.Errorf("expected a score of 0 without detection, got %v", score)
+	}
+}
@@ -1,5 +1,17 @@
 package chip8
 
+import "fmt"
+
+// Addresses at which the fonts are loaded by default, following the
+// conventional layout with the 4x5 font at 0x050-0x09F.
+const (
+	defaultFontAddress = 0x050
+	bigFontAddress     = 0x0A0
+)
+
+// fontGlyphSize is the number of bytes in each 4x5 glyph of a font set.
+const fontGlyphSize = 5
+
 var chip8Fontset = []byte{
 	0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
 	0x20, 0x60, 0x20, 0x20, 0x70, // 1
@@ -18,3 +30,55 @@ var chip8Fontset = []byte{
 	0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
 	0xF0, 0x80, 0xF0, 0x80, 0x80, // F
 }
+
+// superChipBigFontset contains the SUPER-CHIP 8x10 font for digits 0-9.
+var superChipBigFontset = []byte{
+	0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+	0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, // 7
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
+}
+
+// SetFontSet replaces the 4x5 font used by FX29 with data loaded at baseAddr.
+// The font must contain a 5 byte glyph for each of the digits 0-F, in order.
+// The font is loaded immediately, and again whenever the machine is reset.
+// It may not overlap the loaded ROM, or the SUPER-CHIP 8x10 font used by
+// FX30, which is always loaded at 0x0A0 and cannot be replaced.
+func (c *Chip8) SetFontSet(data []byte, baseAddr uint16) error {
+	if len(data) != 16*fontGlyphSize {
+		return fmt.Errorf("font set of %d bytes, expected %d", len(data), 16*fontGlyphSize)
+	}
+	start, end := int(baseAddr), int(baseAddr)+len(data)
+	if end > len(c.memory) {
+		return fmt.Errorf("font set at 0x%03X: %w", baseAddr, ErrMemoryOutOfRange)
+	}
+	if overlaps(start, end, bigFontAddress, bigFontAddress+len(superChipBigFontset)) {
+		return fmt.Errorf("font set at 0x%03X overlaps the big font at 0x%03X", baseAddr, bigFontAddress)
+	}
+	romStart := int(c.loadAddress)
+	if overlaps(start, end, romStart, romStart+c.romSize) {
+		return fmt.Errorf("font set at 0x%03X overlaps the ROM at 0x%03X", baseAddr, romStart)
+	}
+	c.font = append([]byte(nil), data...)
+	c.fontAddress = baseAddr
+	c.loadFont()
+	return nil
+}
+
+// overlaps returns true iff the address ranges [start1, end1) and
+// [start2, end2) have any addresses in common.
+func overlaps(start1, end1, start2, end2 int) bool {
+	return start1 < end2 && start2 < end1
+}
+
+// loadFont copies the fonts into memory.
+func (c *Chip8) loadFont() {
+	copy(c.memory[bigFontAddress:], superChipBigFontset)
+	copy(c.memory[c.fontAddress:], c.font)
+}
@@ -0,0 +1,90 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDefaultFontAddress(t *testing.T) {
+	cpu := initCPU()
+	for d := 0; d < 16; d++ {
+		cpu.V[3] = byte(d)
+		if _, err := cpu.opcode0xF000(0xF329); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := uint16(defaultFontAddress + d*fontGlyphSize)
+		if cpu.I != expected {
+			t.Errorf("digit %X: expected I = 0x%03X, got 0x%03X", d, expected, cpu.I)
+		}
+	}
+	if !bytes.Equal(cpu.memory[defaultFontAddress:defaultFontAddress+80], chip8Fontset) {
+		t.Errorf("expected font set at 0x%03X", defaultFontAddress)
+	}
+}
+
+func TestSetFontSet(t *testing.T) {
+	cpu := initCPU()
+	font := make([]byte, 80)
+	for i := range font {
+		font[i] = byte(i + 1)
+	}
+	if err := cpu.SetFontSet(font, 0x110); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(cpu.memory[0x110:0x160], font) {
+		t.Errorf("expected custom font at 0x110")
+	}
+
+	// The font is copied, so later changes to the slice are not visible
+	font[0] = 0xFF
+	cpu.Reset()
+	if cpu.memory[0x110] != 1 {
+		t.Errorf("expected custom font to be reloaded on reset, got 0x%02X", cpu.memory[0x110])
+	}
+
+	cpu.V[0] = 0xA
+	if _, err := cpu.opcode0xF000(0xF029); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu.I != 0x110+0xA*fontGlyphSize {
+		t.Errorf("expected I = 0x%03X, got 0x%03X", 0x110+0xA*fontGlyphSize, cpu.I)
+	}
+}
+
+func TestSetFontSetErrors(t *testing.T) {
+	cpu := initCPU()
+	if err := cpu.SetFontSet(make([]byte, 79), defaultFontAddress); err == nil {
+		t.Errorf("expected error for short font set")
+	}
+	err := cpu.SetFontSet(make([]byte, 80), 0xFC0)
+	if !errors.Is(err, ErrMemoryOutOfRange) {
+		t.Errorf("expected ErrMemoryOutOfRange, got %v", err)
+	}
+	if cpu.fontAddress != defaultFontAddress {
+		t.Errorf("expected font address to be unchanged, got 0x%03X", cpu.fontAddress)
+	}
+}
+
+func TestSetFontSetOverlap(t *testing.T) {
+	cpu, err := New(bytes.NewReader(BuildROM(0x1200, 0x1200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []uint16{0x060, 0x0A0, 0x0F0, 0x1B1, 0x200, 0x203} {
+		if err := cpu.SetFontSet(make([]byte, 80), addr); err == nil {
+			t.Errorf("0x%03X: expected error for overlapping font set", addr)
+		}
+	}
+	if !bytes.Equal(cpu.memory[0x200:0x204], BuildROM(0x1200, 0x1200)) {
+		t.Errorf("expected ROM to be unchanged, got % X", cpu.memory[0x200:0x204])
+	}
+
+	// Adjacent to both the big font and the ROM
+	if err := cpu.SetFontSet(make([]byte, 80), 0x104); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := cpu.SetFontSet(make([]byte, 80), 0x204); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package chip8
+
+// snapshot is a copy of the machine state before a cycle, restored by StepBack.
+type snapshot struct {
+	memory [4096]byte
+	V      [16]byte
+	rpl    [8]byte
+	I      uint16
+	pc     uint16
+	sp     uint16
+	stack  [16]uint16
+
+	gfx, gfx2     []byte
+	width, height int
+	planeMask     byte
+
+	delayTimer byte
+	soundTimer byte
+	vblank     bool
+
+	waitingForKey  bool
+	keyRegister    uint16
+	waitKey        byte
+	waitKeyPressed bool
+	halted         bool
+}
+
+// SetHistoryDepth sets the number of cycles that can be undone with StepBack.
+// History is not recorded by default, as it copies the machine state on every
+// cycle. Any existing history is discarded. A depth of 0 disables recording
+// history again.
+func (c *Chip8) SetHistoryDepth(n int) {
+	c.historyDepth = n
+	c.history = nil
+	c.clearHistory()
+}
+
+// StepBack restores the state from before the most recent cycle, allowing
+// execution to be rewound one cycle at a time.
+// Keys, metrics and the draw flag are not restored.
+// ErrNoHistory is returned if there are no more cycles to step back through.
+func (c *Chip8) StepBack() error {
+	if c.historyLen == 0 {
+		return ErrNoHistory
+	}
+	c.historyNext = (c.historyNext - 1 + c.historyDepth) % c.historyDepth
+	c.historyLen--
+	c.restoreSnapshot(&c.history[c.historyNext])
+	return nil
+}
+
+// clearHistory forgets all recorded cycles, so that StepBack cannot rewind
+// past a change of state made outside of execution.
+func (c *Chip8) clearHistory() {
+	c.historyNext = 0
+	c.historyLen = 0
+}
+
+// recordHistory saves the current state into the history ring.
+func (c *Chip8) recordHistory() {
+	if c.historyDepth <= 0 {
+		return
+	}
+	// Grow the ring as needed, up to the history depth
+	if c.historyNext == len(c.history) {
+		c.history = append(c.history, snapshot{})
+	}
+	s := &c.history[c.historyNext]
+	s.memory = c.memory
+	s.V = c.V
+	s.rpl = c.rpl
+	s.I = c.I
+	s.pc = c.pc
+	s.sp = c.sp
+	s.stack = c.stack
+	s.gfx = append(s.gfx[:0], c.gfx...)
+	s.gfx2 = append(s.gfx2[:0], c.gfx2...)
+	s.width, s.height = c.width, c.height
+	s.planeMask = c.planeMask
+	s.delayTimer = c.delayTimer
+	s.soundTimer = c.soundTimer
+	s.vblank = c.vblank
+	s.waitingForKey = c.waitingForKey
+	s.keyRegister = c.keyRegister
+	s.waitKey = c.waitKey
+	s.waitKeyPressed = c.waitKeyPressed
+	s.halted = c.halted
+
+	c.historyNext = (c.historyNext + 1) % c.historyDepth
+	if c.historyLen < c.historyDepth {
+		c.historyLen++
+	}
+}
+
+// restoreSnapshot sets the machine state from s.
+func (c *Chip8) restoreSnapshot(s *snapshot) {
+	c.memory = s.memory
+	c.V = s.V
+	c.rpl = s.rpl
+	c.I = s.I
+	c.pc = s.pc
+	c.sp = s.sp
+	c.stack = s.stack
+	if c.flicker != nil && len(s.gfx) != len(c.gfx) {
+		c.flicker.resize(len(s.gfx))
+	}
+	c.gfx = append([]byte(nil), s.gfx...)
+	c.gfx2 = append([]byte(nil), s.gfx2...)
+	c.width, c.height = s.width, s.height
+	c.planeMask = s.planeMask
+	c.delayTimer = s.delayTimer
+	c.soundTimer = s.soundTimer
+	c.vblank = s.vblank
+	c.waitingForKey = s.waitingForKey
+	c.keyRegister = s.keyRegister
+	c.waitKey = s.waitKey
+	c.waitKeyPressed = s.waitKeyPressed
+	c.halted = s.halted
+	c.markAllDirty()
+	c.drawFlag = true
+}
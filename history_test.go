@@ -0,0 +1,146 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestStepBack(t *testing.T) {
+	// V0 = 5; I = 0x300; reg_dump(V0); draw(V0,V0,1); call 0x20C; goto 0x20A; V1 = 7
+	rom := BuildROM(0x6005, 0xA300, 0xF055, 0xD001, 0x220C, 0x120A, 0x6107)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.SetHistoryDepth(16)
+
+	var states []string
+	dump := func() string {
+		// The draw flag is not restored
+		cpu.DrawFlag()
+		var text bytes.Buffer
+		if err := cpu.DumpStateText(&text); err != nil {
+			t.Fatal(err)
+		}
+		return text.String()
+	}
+	for i := 0; i < 6; i++ {
+		states = append(states, dump())
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expectRegister(t, cpu, 1, 7)
+
+	// Rewind each cycle in turn
+	for i := len(states) - 1; i >= 0; i-- {
+		if err := cpu.StepBack(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := dump(); got != states[i] {
+			t.Errorf("state after stepping back to cycle %d did not match:\n%s\nexpected:\n%s", i, got, states[i])
+		}
+	}
+	if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("expected no history error, got %v", err)
+	}
+}
+
+func TestHistoryDepth(t *testing.T) {
+	// V0 += 1; goto 0x200
+	cpu, err := New(bytes.NewReader(BuildROM(0x7001, 0x1200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.SetHistoryDepth(3)
+	for i := 0; i < 10; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expectRegister(t, cpu, 0, 5)
+
+	// Only the last 3 cycles are kept
+	for i := 0; i < 3; i++ {
+		if err := cpu.StepBack(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expectRegister(t, cpu, 0, 4)
+	expectPC(t, cpu, 0x202)
+	if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("expected no history error, got %v", err)
+	}
+
+	// History can be disabled
+	cpu.SetHistoryDepth(0)
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("expected no history error, got %v", err)
+	}
+}
+
+func TestHistoryDisabledByDefault(t *testing.T) {
+	// V0 += 1; goto 0x200
+	cpu, err := New(bytes.NewReader(BuildROM(0x7001, 0x1200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("expected no history error, got %v", err)
+	}
+}
+
+func TestHistoryClearedByRestore(t *testing.T) {
+	// V0 += 1; goto 0x200
+	rom := BuildROM(0x7001, 0x1200)
+	for _, test := range []struct {
+		name    string
+		restore func(cpu *Chip8) error
+	}{
+		{
+			name: "json",
+			restore: func(cpu *Chip8) error {
+				return cpu.Restore(cpu.Snapshot())
+			},
+		},
+		{
+			name: "text",
+			restore: func(cpu *Chip8) error {
+				var text bytes.Buffer
+				if err := cpu.DumpStateText(&text); err != nil {
+					return err
+				}
+				return cpu.LoadStateText(&text)
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, err := New(bytes.NewReader(rom))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cpu.SetHistoryDepth(16)
+			for i := 0; i < 2; i++ {
+				if _, err := cpu.EmulateCycle(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if err := test.restore(cpu); err != nil {
+				t.Fatal(err)
+			}
+
+			// Cycles from before the restore cannot be stepped back into
+			if err := cpu.StepBack(); !errors.Is(err, ErrNoHistory) {
+				t.Errorf("expected no history error, got %v", err)
+			}
+			expectRegister(t, cpu, 0, 1)
+		})
+	}
+}
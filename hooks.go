@@ -0,0 +1,28 @@
+package chip8
+
+// OnDelayTimerRead registers a function to be called with the value of the
+// delay timer whenever it is read by the program (opcode FX07).
+// Programs often poll the delay timer in a tight loop, so a frontend may use
+// this to detect busy waiting.
+// Passing nil removes any existing hook.
+func (c *Chip8) OnDelayTimerRead(hook func(value byte)) {
+	c.onDelayTimerRead = hook
+}
+
+// OnFlagChange registers a function to be called whenever the value of the
+// flag register VF is changed by an opcode that reports a status through it.
+// The reason is one of "carry", "borrow", "collision", "shift", "overflow"
+// or "reset".
+// Passing nil removes any existing hook.
+func (c *Chip8) OnFlagChange(hook func(old, new byte, reason string)) {
+	c.onFlagChange = hook
+}
+
+// setFlag sets VF to value, notifying any hook if it changed.
+func (c *Chip8) setFlag(value byte, reason string) {
+	old := c.V[0xF]
+	c.V[0xF] = value
+	if c.onFlagChange != nil && old != value {
+		c.onFlagChange(old, value, reason)
+	}
+}
@@ -0,0 +1,73 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOnDelayTimerRead(t *testing.T) {
+	// V0 = delay; goto 0x200
+	cpu, err := New(bytes.NewReader(BuildROM(0xF007, 0x1200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.delayTimer = 0x30
+
+	var reads []byte
+	cpu.OnDelayTimerRead(func(value byte) {
+		reads = append(reads, value)
+	})
+
+	for i := 0; i < 10; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(reads) != 5 {
+		t.Fatalf("expected 5 reads, got %d", len(reads))
+	}
+	for _, value := range reads {
+		// The delay timer may have ticked down in real time
+		if value > 0x30 || value < 0x2F {
+			t.Errorf("unexpected delay timer value 0x%X", value)
+		}
+	}
+}
+
+func TestOnFlagChange(t *testing.T) {
+	// V0 = 0xFF; V1 = 2; V0 += V1; V0 += V1
+	cpu, err := New(bytes.NewReader(BuildROM(0x60FF, 0x6102, 0x8014, 0x8014)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		old, new byte
+		reason   string
+	}
+	var changes []change
+	cpu.OnFlagChange(func(old, new byte, reason string) {
+		changes = append(changes, change{old, new, reason})
+	})
+
+	for i := 0; i < 4; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Only the first addition carries, the second clears the flag
+	expected := []change{
+		{old: 0, new: 1, reason: "carry"},
+		{old: 1, new: 0, reason: "carry"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("expected change %+v, got %+v", expected[i], changes[i])
+		}
+	}
+}
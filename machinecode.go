@@ -0,0 +1,64 @@
+package chip8
+
+import "fmt"
+
+// MachineCodeBehavior determines what happens when a program calls a machine
+// code routine with 0NNN.
+type MachineCodeBehavior int
+
+const (
+	// MachineCodeIgnore skips the call and continues with the next opcode.
+	// This is the default.
+	MachineCodeIgnore MachineCodeBehavior = iota
+	// MachineCodeError causes EmulateCycle to return an error wrapping
+	// ErrUnknownOpcode.
+	MachineCodeError
+	// MachineCodeCallback calls the hook registered with OnMachineCode,
+	// continuing with the next opcode unless it returns an error.
+	// If no hook is registered, calls are ignored.
+	MachineCodeCallback
+)
+
+// WithMachineCodeBehavior configures what happens when a program calls a
+// machine code routine.
+func WithMachineCodeBehavior(b MachineCodeBehavior) Option {
+	return func(c *Chip8) {
+		c.machineCode = b
+	}
+}
+
+// OnMachineCode registers a function to be called with the address of the
+// routine whenever a program calls machine code with 0NNN, when configured
+// with MachineCodeCallback. Any error returned by the hook is returned from
+// EmulateCycle, without advancing past the call.
+// Passing nil removes any existing hook.
+func (c *Chip8) OnMachineCode(hook func(addr uint16) error) {
+	c.onMachineCode = hook
+}
+
+// describeMachineCode describes a call to the machine code routine at addr,
+// which is unknown if configured with MachineCodeError.
+func (c *Chip8) describeMachineCode(addr uint16) (string, pseudocode) {
+	switch {
+	case c.machineCode == MachineCodeError:
+		return "", pseudocode{}
+	case c.machineCode == MachineCodeCallback && c.onMachineCode != nil:
+		return "0x0NNN", pseudo("call_machine_code(0x%03X)", addr)
+	}
+	return "0x0NNN", pseudo("call_machine_code(0x%03X) ignored", addr)
+}
+
+// callMachineCode handles a call to the machine code routine at addr.
+func (c *Chip8) callMachineCode(addr uint16) (Result, error) {
+	switch {
+	case c.machineCode == MachineCodeError:
+		return Result{}, fmt.Errorf("%w: machine code routine at 0x%03X", ErrUnknownOpcode, addr)
+	case c.machineCode == MachineCodeCallback && c.onMachineCode != nil:
+		if err := c.onMachineCode(addr); err != nil {
+			return Result{}, err
+		}
+	}
+	c.pc += 2
+	// The opcode of a call is the address of the routine
+	return c.describe(addr), nil
+}
@@ -0,0 +1,85 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMachineCodeBehavior(t *testing.T) {
+	// V0 = 1; call machine code at 0x123; V1 = 2
+	rom := BuildROM(0x6001, 0x0123, 0x6102)
+	errRoutine := errors.New("routine failed")
+
+	var tests = []struct {
+		name     string
+		behavior MachineCodeBehavior
+		hook     func(addr uint16) error
+		// Expected error, and the pseudocode of the call if there is none
+		err    error
+		pseudo string
+	}{
+		{
+			name:   "ignore",
+			pseudo: "call_machine_code(0x123) ignored",
+		},
+		{
+			name:     "error",
+			behavior: MachineCodeError,
+			err:      ErrUnknownOpcode,
+		},
+		{
+			name:     "callback",
+			behavior: MachineCodeCallback,
+			hook:     func(addr uint16) error { return nil },
+			pseudo:   "call_machine_code(0x123)",
+		},
+		{
+			name:     "callback error",
+			behavior: MachineCodeCallback,
+			hook:     func(addr uint16) error { return errRoutine },
+			err:      errRoutine,
+		},
+		{
+			name:     "callback without hook",
+			behavior: MachineCodeCallback,
+			pseudo:   "call_machine_code(0x123) ignored",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, err := New(bytes.NewReader(rom), WithMachineCodeBehavior(test.behavior), WithFullResults())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var calls []uint16
+			if test.hook != nil {
+				cpu.OnMachineCode(func(addr uint16) error {
+					calls = append(calls, addr)
+					return test.hook(addr)
+				})
+			}
+
+			results, err := cpu.Step(3)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected %v, got %v", test.err, err)
+				}
+				// Execution stops at the call
+				expectPC(t, cpu, 0x202)
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if results[1].Pseudo != test.pseudo {
+					t.Errorf("expected pseudo %q, got %q", test.pseudo, results[1].Pseudo)
+				}
+				expectRegister(t, cpu, 1, 2)
+			}
+
+			if test.hook != nil && (len(calls) != 1 || calls[0] != 0x123) {
+				t.Errorf("expected a single call to 0x123, got %v", calls)
+			}
+		})
+	}
+}
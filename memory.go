@@ -0,0 +1,81 @@
+package chip8
+
+import "fmt"
+
+// LoadSprite writes sprite data into memory at addr and points the index
+// register at it, ready to be drawn with DXYN.
+// An error wrapping ErrMemoryOutOfRange is returned if the sprite would not
+// fit in memory, in which case the machine is unchanged.
+func (c *Chip8) LoadSprite(addr uint16, sprite []byte) error {
+	if int(addr)+len(sprite) > len(c.memory) {
+		return fmt.Errorf("sprite of %d bytes at 0x%03X: %w", len(sprite), addr, ErrMemoryOutOfRange)
+	}
+	copy(c.memory[addr:], sprite)
+	c.I = addr
+	return nil
+}
+
+// PeekByte returns the byte stored in memory at addr, without running
+// anything. An error wrapping ErrMemoryOutOfRange is returned if addr is
+// beyond the end of memory.
+func (c *Chip8) PeekByte(addr uint16) (byte, error) {
+	if int(addr) >= len(c.memory) {
+		return 0, fmt.Errorf("read at 0x%X: %w", addr, ErrMemoryOutOfRange)
+	}
+	return c.memory[addr], nil
+}
+
+// PokeByte sets the byte stored in memory at addr, for setting up tests or
+// applying cheats. An error wrapping ErrMemoryOutOfRange is returned if addr
+// is beyond the end of memory.
+// Writing below 0x200 is allowed, but is unusual as this area holds the
+// fonts. Changes to the fonts are undone by Reset. Watchpoints are not
+// triggered.
+func (c *Chip8) PokeByte(addr uint16, value byte) error {
+	if int(addr) >= len(c.memory) {
+		return fmt.Errorf("write at 0x%X: %w", addr, ErrMemoryOutOfRange)
+	}
+	c.memory[addr] = value
+	return nil
+}
+
+// DumpMemory returns a copy of the full 4K of memory.
+func (c *Chip8) DumpMemory() [4096]byte {
+	return c.memory
+}
+
+// DumpMemoryRange returns a copy of the memory from start up to, but not
+// including, end. An error wrapping ErrMemoryOutOfRange is returned if the
+// range extends beyond the end of memory or end is before start.
+func (c *Chip8) DumpMemoryRange(start, end uint16) ([]byte, error) {
+	if end < start || int(end) > len(c.memory) {
+		return nil, fmt.Errorf("read of 0x%X-0x%X: %w", start, end, ErrMemoryOutOfRange)
+	}
+	return append([]byte(nil), c.memory[start:end]...), nil
+}
+
+// checkIndexRange returns an error wrapping ErrMemoryOutOfRange if n bytes
+// starting at I extend beyond memory. Opcodes accessing memory through I
+// check the range before making any changes.
+func (c *Chip8) checkIndexRange(n int) error {
+	if int(c.I)+n <= len(c.memory) {
+		return nil
+	}
+	// The first offset from I that is out of range
+	offset := len(c.memory) - int(c.I)
+	if offset < 0 {
+		offset = 0
+	}
+	return fmt.Errorf("%w: I=0x%03X offset %d", ErrMemoryOutOfRange, c.I, offset)
+}
+
+// checkJumpTarget returns an error wrapping ErrPCOutOfRange if execution
+// cannot continue at addr, as there is no room to fetch an opcode.
+// Opcodes that set the program counter check the target before making any
+// changes, so the error identifies the jump rather than the failed fetch.
+func (c *Chip8) checkJumpTarget(addr uint16) error {
+	if int(addr)+1 >= len(c.memory) {
+		return fmt.Errorf("%w: jump to 0x%X", ErrPCOutOfRange, addr)
+	}
+	return nil
+}
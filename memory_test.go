@@ -0,0 +1,189 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLoadSprite(t *testing.T) {
+	cpu := initCPU()
+	// A 5 row sprite of a hollow square
+	sprite := []byte{0xF0, 0x90, 0x90, 0x90, 0xF0}
+	if err := cpu.LoadSprite(0x300, sprite); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cpu.I != 0x300 {
+		t.Errorf("expected I to be 0x300, got 0x%X", cpu.I)
+	}
+
+	cpu.V[0] = 2
+	cpu.V[1] = 3
+	if _, err := cpu.opcode0xD000(0xD015); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	graphics := cpu.GetGraphics()
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			var expected byte
+			if x >= 2 && x < 6 && y >= 3 && y < 8 {
+				row := sprite[y-3]
+				if row&(0x80>>uint(x-2)) != 0 {
+					expected = 1
+				}
+			}
+			if p := graphics[y*64+x]; p != expected {
+				t.Errorf("expected pixel (%d,%d) to be %d, got %d", x, y, expected, p)
+			}
+		}
+	}
+}
+
+func TestLoadSpriteOutOfRange(t *testing.T) {
+	cpu := initCPU()
+	err := cpu.LoadSprite(0xFFE, []byte{0xFF, 0xFF, 0xFF})
+	if !errors.Is(err, ErrMemoryOutOfRange) {
+		t.Fatalf("expected memory out of range error, got %v", err)
+	}
+	if cpu.I != 0 || cpu.memory[0xFFE] != 0 {
+		t.Errorf("expected machine to be unchanged")
+	}
+}
+
+func TestPeekByte(t *testing.T) {
+	cpu := initCPU()
+	cpu.memory[0xFFF] = 0xAB
+	b, err := cpu.PeekByte(0xFFF)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b != 0xAB {
+		t.Errorf("expected 0xAB, got 0x%02X", b)
+	}
+
+	if _, err := cpu.PeekByte(0x1000); !errors.Is(err, ErrMemoryOutOfRange) {
+		t.Errorf("expected memory out of range error, got %v", err)
+	}
+}
+
+func TestPokeByte(t *testing.T) {
+	cpu, err := New(bytes.NewReader(BuildROM(0x00E0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replace the first opcode with V3 = 0x42
+	if err := cpu.PokeByte(0x200, 0x63); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cpu.PokeByte(0x201, 0x42); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0x6XNN")
+	expectRegister(t, cpu, 3, 0x42)
+
+	// The font may be overwritten
+	if err := cpu.PokeByte(defaultFontAddress, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := cpu.PeekByte(defaultFontAddress); b != 0 {
+		t.Errorf("expected font byte to be overwritten, got 0x%02X", b)
+	}
+
+	if err := cpu.PokeByte(0x1000, 0xFF); !errors.Is(err, ErrMemoryOutOfRange) {
+		t.Errorf("expected memory out of range error, got %v", err)
+	}
+}
+
+func TestDumpMemory(t *testing.T) {
+	cpu := initCPU()
+	cpu.memory[0x300] = 0x12
+	memory := cpu.DumpMemory()
+	if memory[0x300] != 0x12 || memory[defaultFontAddress] != chip8Fontset[0] {
+		t.Errorf("expected dump to match memory")
+	}
+
+	// The dump is a copy
+	memory[0x300] = 0
+	if cpu.memory[0x300] != 0x12 {
+		t.Errorf("expected memory to be unchanged by modifying the dump")
+	}
+}
+
+func TestDumpMemoryRange(t *testing.T) {
+	cpu := initCPU()
+	for i := 0; i < 4; i++ {
+		if err := cpu.PokeByte(uint16(0xFFC+i), byte(i+1)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	b, err := cpu.DumpMemoryRange(0xFFC, 0x1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(b, []byte{1, 2, 3, 4}) {
+		t.Errorf("expected 01 02 03 04, got % X", b)
+	}
+
+	// The range is a copy
+	b[0] = 0xFF
+	if cpu.memory[0xFFC] != 1 {
+		t.Errorf("expected memory to be unchanged by modifying the range")
+	}
+
+	if b, err := cpu.DumpMemoryRange(0x300, 0x300); err != nil || len(b) != 0 {
+		t.Errorf("expected an empty range, got % X, %v", b, err)
+	}
+
+	for _, r := range [][2]uint16{{0xFFC, 0x1001}, {0x301, 0x300}} {
+		if _, err := cpu.DumpMemoryRange(r[0], r[1]); !errors.Is(err, ErrMemoryOutOfRange) {
+			t.Errorf("expected memory out of range error for 0x%X-0x%X, got %v", r[0], r[1], err)
+		}
+	}
+}
+
+func TestIndexRange(t *testing.T) {
+	var tests = []struct {
+		name   string
+		opcode uint16
+		// Highest I at which the opcode stays within memory
+		lastI uint16
+	}{
+		{name: "DXYN", opcode: 0xD013, lastI: 0xFFD},
+		{name: "FX33", opcode: 0xF033, lastI: 0xFFD},
+		{name: "FX55", opcode: 0xF355, lastI: 0xFFC},
+		{name: "FX65", opcode: 0xF365, lastI: 0xFFC},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.I = test.lastI
+			if _, err := cpu.opcodes[test.opcode&0xF000](test.opcode); err != nil {
+				t.Fatalf("unexpected error at I=0x%03X: %v", cpu.I, err)
+			}
+
+			cpu = initCPU()
+			cpu.I = test.lastI + 1
+			cpu.V[0] = 0x12
+			cpu.memory[0xFFF] = 0x34
+			_, err := cpu.opcodes[test.opcode&0xF000](test.opcode)
+			if !errors.Is(err, ErrMemoryOutOfRange) {
+				t.Fatalf("expected memory out of range, got %v", err)
+			}
+			expected := fmt.Sprintf("memory access out of range: I=0x%03X offset %d", cpu.I, 0x1000-int(cpu.I))
+			if err.Error() != expected {
+				t.Errorf("expected error %q, got %q", expected, err.Error())
+			}
+			// Nothing is changed
+			expectPC(t, cpu, 0x200)
+			expectRegister(t, cpu, 0, 0x12)
+			if cpu.memory[0xFFF] != 0x34 {
+				t.Errorf("expected memory to be unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package chip8
+
+import (
+	"fmt"
+	"time"
+)
+
+// MetricsSummary aggregates the activity of a machine since it was created.
+type MetricsSummary struct {
+	// Cycles executed
+	Cycles uint64
+	// 60Hz timer ticks observed
+	Frames uint64
+	// Sprites drawn
+	Draws uint64
+	// Beeps output
+	Beeps uint64
+	// Most cycles executed between two timer ticks
+	PeakCyclesPerFrame uint64
+}
+
+// AverageCyclesPerFrame returns the mean number of cycles executed per
+// timer tick, or zero if no ticks have occurred.
+func (m MetricsSummary) AverageCyclesPerFrame() float64 {
+	if m.Frames == 0 {
+		return 0
+	}
+	return float64(m.Cycles) / float64(m.Frames)
+}
+
+// Format returns a single-line summary of the metrics for a session that
+// lasted the given wall-clock duration.
+func (m MetricsSummary) Format(duration time.Duration) string {
+	return fmt.Sprintf(
+		"duration=%v cycles=%d frames=%d cycles/frame=%.1f peak=%d draws=%d beeps=%d",
+		duration.Round(time.Millisecond),
+		m.Cycles,
+		m.Frames,
+		m.AverageCyclesPerFrame(),
+		m.PeakCyclesPerFrame,
+		m.Draws,
+		m.Beeps,
+	)
+}
+
+// MetricsSummary returns a snapshot of the metrics collected so far.
+func (c *Chip8) MetricsSummary() MetricsSummary {
+	summary := c.metrics
+	summary.Cycles = c.cycles
+	return summary
+}
+
+// countFrame records the end of a frame at a timer tick.
+func (c *Chip8) countFrame() {
+	c.metrics.Frames++
+	if c.frameCycles > c.metrics.PeakCyclesPerFrame {
+		c.metrics.PeakCyclesPerFrame = c.frameCycles
+	}
+	c.frameCycles = 0
+}
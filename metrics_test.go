@@ -0,0 +1,59 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestMetricsSummaryFormat(t *testing.T) {
+	summary := MetricsSummary{
+		Cycles:             1000,
+		Frames:             200,
+		Draws:              30,
+		Beeps:              2,
+		PeakCyclesPerFrame: 9,
+	}
+	expected := "duration=3.5s cycles=1000 frames=200 cycles/frame=5.0 peak=9 draws=30 beeps=2"
+	if got := summary.Format(3500 * time.Millisecond); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if got := (MetricsSummary{Cycles: 5}).AverageCyclesPerFrame(); got != 0 {
+		t.Errorf("expected no average without frames, got %v", got)
+	}
+}
+
+func TestMetricsSummaryAggregation(t *testing.T) {
+	// I = 0x50; draw(V0,V0,1); goto 0x202
+	// Stop time so ticks are driven by the test
+	cpu, err := New(bytes.NewReader(BuildROM(0xA050, 0xD001, 0x1202)), WithClock(&ManualClock{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(cycles int) {
+		for i := 0; i < cycles; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	run(6)
+	cpu.startSound(1)
+	cpu.updateTimers()
+	run(2)
+	cpu.updateTimers()
+
+	expected := MetricsSummary{
+		Cycles:             8,
+		Frames:             2,
+		Draws:              4,
+		Beeps:              1,
+		PeakCyclesPerFrame: 6,
+	}
+	if got := cpu.MetricsSummary(); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
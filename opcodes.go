@@ -1,10 +1,5 @@
 package chip8
 
-import (
-	"fmt"
-	"math/rand"
-)
-
 // opcodeHandler processes an opcode and returns a Result describing the
 // operation performed, or an error if the opcode could not be handled.
 type opcodeHandler func(opcode uint16) (Result, error)
@@ -33,43 +28,69 @@ func (c *Chip8) registerOpcodeHandlers() {
 }
 
 func (c *Chip8) opcode0x0000(opcode uint16) (Result, error) {
-	result := Result{}
-
-	switch opcode & 0x00FF {
-	case 0x00E0:
-		result.OpcodeType = "0x00E0"
-		result.Pseudo = fmt.Sprint("disp_clear()")
-		// Clear display
-		c.gfx = [64 * 32]byte{}
+	switch {
+	case opcode == 0x00E0:
+		c.clearPlanes(c.planeMask)
 		c.pc += 2
-	case 0x00EE:
-		result.OpcodeType = "0x00EE"
-		result.Pseudo = fmt.Sprint("return;")
-		c.pc = c.stack[c.sp] + 2
+	case opcode == 0x00EE:
+		if c.sp == 0 {
+			return Result{}, ErrStackUnderflow
+		}
 		c.sp--
+		c.pc = c.stack[c.sp] + 2
+	case opcode&0xFFF0 == 0x00C0:
+		n := opcode & 0x000F
+		c.scrollDown(int(n))
+		c.pc += 2
+	case opcode&0xFFF0 == 0x00F0:
+		return c.opcode0x00F0(opcode)
+	default:
+		return c.callMachineCode(opcode & 0x0FFF)
+	}
+	return c.describe(opcode), nil
+}
 
+// opcode0x00F0 handles the SUPER-CHIP display instructions 00FB-00FF.
+func (c *Chip8) opcode0x00F0(opcode uint16) (Result, error) {
+	switch opcode {
+	case 0x00FB:
+		c.scrollHorizontal(4)
+		c.pc += 2
+	case 0x00FC:
+		c.scrollHorizontal(-4)
+		c.pc += 2
+	case 0x00FE:
+		c.setResolution(lowResWidth, lowResHeight)
+		c.pc += 2
+	case 0x00FF:
+		c.setResolution(highResWidth, highResHeight)
+		c.pc += 2
 	default:
-		return result, fmt.Errorf("unknown opcode: 0x%X", opcode)
+		return Result{}, ErrUnknownOpcode
 	}
-	return result, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x1000(opcode uint16) (Result, error) {
+	if err := c.checkJumpTarget(opcode & 0x0FFF); err != nil {
+		return Result{}, err
+	}
 	c.pc = opcode & 0x0FFF
-	return Result{
-		OpcodeType: "0x1NNN",
-		Pseudo:     fmt.Sprintf("goto 0x%X;", c.pc),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x2000(opcode uint16) (Result, error) {
-	c.sp++
+	// The stack pointer is incremented after storing, so counts the entries in use
+	if int(c.sp) >= len(c.stack) {
+		return Result{}, ErrStackOverflow
+	}
+	if err := c.checkJumpTarget(opcode & 0x0FFF); err != nil {
+		return Result{}, err
+	}
 	c.stack[c.sp] = c.pc
+	c.sp++
 	c.pc = opcode & 0x0FFF
-	return Result{
-		OpcodeType: "0x2NNN",
-		Pseudo:     fmt.Sprintf("*(0x%X)()", c.pc),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x3000(opcode uint16) (Result, error) {
@@ -80,10 +101,7 @@ func (c *Chip8) opcode0x3000(opcode uint16) (Result, error) {
 	} else {
 		c.pc += 2
 	}
-	return Result{
-		OpcodeType: "0x3XNN",
-		Pseudo:     fmt.Sprintf("if(V%d==0x%X)", x, nn),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x4000(opcode uint16) (Result, error) {
@@ -94,10 +112,7 @@ func (c *Chip8) opcode0x4000(opcode uint16) (Result, error) {
 	} else {
 		c.pc += 2
 	}
-	return Result{
-		OpcodeType: "0x4XNN",
-		Pseudo:     fmt.Sprintf("if(V%d!=0x%X)", x, nn),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x5000(opcode uint16) (Result, error) {
@@ -108,10 +123,7 @@ func (c *Chip8) opcode0x5000(opcode uint16) (Result, error) {
 	} else {
 		c.pc += 2
 	}
-	return Result{
-		OpcodeType: "0x5XY0",
-		Pseudo:     fmt.Sprintf("if(V%d==V%d)", x, y),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x6000(opcode uint16) (Result, error) {
@@ -119,10 +131,7 @@ func (c *Chip8) opcode0x6000(opcode uint16) (Result, error) {
 	nn := byte(opcode & 0x00FF)
 	c.V[x] = nn
 	c.pc += 2
-	return Result{
-		OpcodeType: "0x6XNN",
-		Pseudo:     fmt.Sprintf("V%d = 0x%X", x, nn),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x7000(opcode uint16) (Result, error) {
@@ -130,83 +139,81 @@ func (c *Chip8) opcode0x7000(opcode uint16) (Result, error) {
 	nn := byte(opcode & 0x00FF)
 	c.V[x] += nn
 	c.pc += 2
-	return Result{
-		OpcodeType: "0x7XNN",
-		Pseudo:     fmt.Sprintf("V%d += 0x%X", x, nn),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0x8000(opcode uint16) (Result, error) {
-	result := Result{}
 	x := (opcode & 0x0F00) >> 8
 	y := (opcode & 0x00F0) >> 4
 	switch opcode & 0x000F {
 	case 0x0000:
 		c.V[x] = c.V[y]
 		c.pc += 2
-		result.OpcodeType = "0x8XY0"
-		result.Pseudo = fmt.Sprintf("V%d = V%d", x, y)
 	case 0x0001:
 		c.V[x] |= c.V[y]
 		c.pc += 2
-		result.OpcodeType = "0x8XY1"
-		result.Pseudo = fmt.Sprintf("V%d |= V%d", x, y)
+		if c.quirks.VFReset {
+			c.setFlag(0, "reset")
+		}
 	case 0x0002:
 		c.V[x] &= c.V[y]
 		c.pc += 2
-		result.OpcodeType = "0x8XY2"
-		result.Pseudo = fmt.Sprintf("V%d &= V%d", x, y)
+		if c.quirks.VFReset {
+			c.setFlag(0, "reset")
+		}
 	case 0x0003:
 		c.V[x] ^= c.V[y]
 		c.pc += 2
-		result.OpcodeType = "0x8XY3"
-		result.Pseudo = fmt.Sprintf("V%d ^= V%d", x, y)
+		if c.quirks.VFReset {
+			c.setFlag(0, "reset")
+		}
 	case 0x0004:
 		if c.V[y] > (0xFF - c.V[x]) {
-			c.V[0xF] = 1 //carry
+			c.setFlag(1, "carry")
 		} else {
-			c.V[0xF] = 0
+			c.setFlag(0, "carry")
 		}
 		c.V[x] += c.V[y]
 		c.pc += 2
-		result.OpcodeType = "0x8XY4"
-		result.Pseudo = fmt.Sprintf("V%d += V%d", x, y)
 	case 0x0005:
 		if c.V[y] > c.V[x] {
-			c.V[0xF] = 0 // borrow
+			c.setFlag(0, "borrow")
 		} else {
-			c.V[0xF] = 1 // no borrow
+			c.setFlag(1, "borrow") // no borrow
 		}
 		c.V[x] -= c.V[y]
 		c.pc += 2
-		result.OpcodeType = "0x8XY5"
-		result.Pseudo = fmt.Sprintf("V%d -= V%d", x, y)
 	case 0x0006:
-		c.V[x] = c.V[y] >> 1
-		c.V[0xF] = c.V[y] & 0x01
+		source := c.shiftSource(x, y)
+		c.V[x] = source >> 1
+		c.setFlag(source&0x01, "shift")
 		c.pc += 2
-		result.OpcodeType = "0x8XY6"
-		result.Pseudo = fmt.Sprintf("V%d=V%d=V%d>>1", x, y, y)
 	case 0x0007:
 		if c.V[x] > c.V[y] {
-			c.V[0xF] = 0 //borrow
+			c.setFlag(0, "borrow")
 		} else {
-			c.V[0xF] = 1
+			c.setFlag(1, "borrow") // no borrow
 		}
 		c.V[x] = c.V[y] - c.V[x]
 		c.pc += 2
-		result.OpcodeType = "0x8XY7"
-		result.Pseudo = fmt.Sprintf("V%d=V%d-V%d", x, y, x)
 	case 0x000E:
-		c.V[x] = c.V[y] << 1
-		c.V[0xF] = c.V[y] & 0x80
+		source := c.shiftSource(x, y)
+		c.V[x] = source << 1
+		c.setFlag((source&0x80)>>7, "shift")
 		c.pc += 2
-		result.OpcodeType = "0x8XYE"
-		result.Pseudo = fmt.Sprintf("V%d=V%d=V%d<<1", x, y, y)
 	default:
-		return Result{}, fmt.Errorf("unknown opcode: 0x%X", opcode)
+		return Result{}, ErrUnknownOpcode
 	}
-	return result, nil
+	return c.describe(opcode), nil
+}
+
+// shiftSource returns the value shifted by 8XY6 and 8XYE.
+// This is VY on the COSMAC VIP, or VX in place with the Shift quirk.
+func (c *Chip8) shiftSource(x, y uint16) byte {
+	if c.quirks.Shift {
+		return c.V[x]
+	}
+	return c.V[y]
 }
 
 func (c *Chip8) opcode0x9000(opcode uint16) (Result, error) {
@@ -217,167 +224,275 @@ func (c *Chip8) opcode0x9000(opcode uint16) (Result, error) {
 	} else {
 		c.pc += 2
 	}
-	return Result{
-		OpcodeType: "0x9XY0",
-		Pseudo:     fmt.Sprintf("if(V%d!=V%d)", x, y),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0xA000(opcode uint16) (Result, error) {
 	c.I = opcode & 0x0FFF
 	c.pc += 2
-	return Result{
-		OpcodeType: "0xANNN",
-		Pseudo:     fmt.Sprintf("I = 0x%X", c.I),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0xB000(opcode uint16) (Result, error) {
 	nnn := opcode & 0x0FFF
+	if c.quirks.Jump {
+		x := (opcode & 0x0F00) >> 8
+		if err := c.checkJumpTarget(uint16(c.V[x]) + nnn); err != nil {
+			return Result{}, err
+		}
+		c.pc = uint16(c.V[x]) + nnn
+		return c.describe(opcode), nil
+	}
+	if err := c.checkJumpTarget(uint16(c.V[0]) + nnn); err != nil {
+		return Result{}, err
+	}
 	c.pc = uint16(c.V[0]) + nnn
-	return Result{
-		OpcodeType: "0xBNNN",
-		Pseudo:     fmt.Sprintf("PC=V0+0x%X", nnn),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0xC000(opcode uint16) (Result, error) {
 	x := uint16(opcode&0x0F00) >> 8
 	nn := opcode & 0x00FF
-	c.V[x] = byte(rand.Float32()*255) & byte(nn)
+	c.V[x] = c.randomByte() & byte(nn)
 	c.pc += 2
-	return Result{
-		OpcodeType: "0xCXNN",
-		Pseudo:     fmt.Sprintf("V%d=rand()&0x%X", x, nn),
-	}, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0xD000(opcode uint16) (Result, error) {
-	x := uint16(c.V[(opcode&0x0F00)>>8])
-	y := uint16(c.V[(opcode&0x00F0)>>4])
+	vx := (opcode & 0x0F00) >> 8
+	vy := (opcode & 0x00F0) >> 4
+	// The starting position wraps around the display
+	x := int(c.V[vx]) % c.width
+	y := int(c.V[vy]) % c.height
 	height := opcode & 0x000F
-	var pixel uint16
+	// DXY0 draws a 16x16 sprite with two bytes per row, as on SUPER-CHIP
+	rows, width := height, uint16(8)
+	if height == 0 {
+		rows, width = 16, 16
+	}
+	size := rows * width / 8
+	planes := c.selectedPlanes()
+	if err := c.checkIndexRange(int(size) * len(planes)); err != nil {
+		return Result{}, err
+	}
 
-	c.V[0xF] = 0
-	for yline := uint16(0); yline < height; yline++ {
-		pixel = uint16(c.memory[c.I+yline])
-		for xline := uint16(0); xline < 8; xline++ {
-			index := (x + xline + ((y + yline) * 64))
-			if index > uint16(len(c.gfx)) {
-				continue
-			}
-			if (pixel & (0x80 >> xline)) != 0 {
-				if c.gfx[index] == 1 {
-					c.V[0xF] = 1
-				}
-				c.gfx[index] ^= 1
-			}
+	// Retry the draw until the display has been refreshed
+	if c.quirks.DisplayWait {
+		if !c.vblank {
+			result := c.describe(opcode)
+			result.OpcodeType = "0xDXYN (waiting)"
+			result.Waiting = true
+			return result, nil
+		}
+		c.vblank = false
+	}
+
+	// Each selected plane is drawn with the next size bytes of sprite data
+	var collision byte
+	addr := c.I
+	for i, plane := range planes {
+		trackFlicker := i == 0 && c.planeMask&0x1 != 0
+		if c.drawSprite(plane, x, y, addr, rows, width, trackFlicker) {
+			collision = 1
 		}
+		addr += size
 	}
+	c.setFlag(collision, "collision")
 
 	c.drawFlag = true
+	c.metrics.Draws++
 	c.pc += 2
 
-	return Result{
-		OpcodeType: "0xDXYN",
-		Pseudo:     fmt.Sprintf("draw(V%d,V%d,%d)", x, y, height),
-	}, nil
+	return c.describe(opcode), nil
+}
+
+// drawSprite XORs the sprite of height rows at addr onto plane at (x,y),
+// returning true if any pixels were turned off. Sprites are 8 or 16 pixels
+// wide, with one or two bytes per row respectively.
+// Pixels beyond the edges of the display are clipped, or wrap around to the
+// opposite edge with the DrawWrap quirk.
+// Only the first plane is tracked for flicker detection.
+func (c *Chip8) drawSprite(plane []byte, x, y int, addr, height, width uint16, trackFlicker bool) bool {
+	var collision bool
+	rowBytes := width / 8
+	for yline := uint16(0); yline < height; yline++ {
+		py := y + int(yline)
+		if py >= c.height {
+			if !c.quirks.DrawWrap {
+				break
+			}
+			py %= c.height
+		}
+		var pixel uint16
+		for b := uint16(0); b < rowBytes; b++ {
+			pixel = pixel<<8 | uint16(c.memory[addr+yline*rowBytes+b])
+		}
+		for xline := uint16(0); xline < width; xline++ {
+			px := x + int(xline)
+			if px >= c.width {
+				if !c.quirks.DrawWrap {
+					break
+				}
+				px %= c.width
+			}
+			index := py*c.width + px
+			if pixel&(1<<(width-1-xline)) != 0 {
+				if plane[index] == 1 {
+					collision = true
+				}
+				plane[index] ^= 1
+				c.markDirty(Region{X: px, Y: py, Width: 1, Height: 1})
+				if c.flicker != nil && trackFlicker {
+					c.flicker.toggled(index, plane[index] != 0, c.metrics.Frames)
+				}
+			}
+		}
+	}
+	return collision
 }
 
 func (c *Chip8) opcode0xE000(opcode uint16) (Result, error) {
-	result := Result{}
 	x := (opcode & 0x0F00) >> 8
 	switch opcode & 0x00FF {
 	case 0x009E:
-		if c.key[c.V[x]] != 0 {
+		// Only the low nibble of VX selects a key
+		if c.key[c.V[x]&0xF] != 0 {
 			c.pc += 4
-			c.key[c.V[x]] = 0
 		} else {
 			c.pc += 2
 		}
-		result.OpcodeType = "0xEX9E"
-		result.Pseudo = fmt.Sprintf("if(key()==V%d)", x)
 	case 0x00A1:
-		if c.key[c.V[x]] == 0 {
+		if c.key[c.V[x]&0xF] == 0 {
 			c.pc += 4
 		} else {
-			c.key[c.V[x]] = 0
 			c.pc += 2
 		}
-		result.OpcodeType = "0xEXA1"
-		result.Pseudo = fmt.Sprintf("if(key()!=V%d)", x)
+	default:
+		return Result{}, ErrUnknownOpcode
 	}
-	return result, nil
+	return c.describe(opcode), nil
 }
 
 func (c *Chip8) opcode0xF000(opcode uint16) (Result, error) {
-	result := Result{}
 	x := (opcode & 0x0F00) >> 8
 	switch opcode & 0x00FF {
+	case 0x0001:
+		// Only two planes are available
+		if x > 0x3 {
+			return Result{}, ErrUnknownOpcode
+		}
+		c.planeMask = byte(x)
+		c.pc += 2
 	case 0x0007:
 		c.V[x] = c.delayTimer
+		if c.onDelayTimerRead != nil {
+			c.onDelayTimerRead(c.delayTimer)
+		}
 		c.pc += 2
-		result.OpcodeType = "0xFX07"
-		result.Pseudo = fmt.Sprint("Vx = get_delay()")
 	case 0x000A:
+		c.waitingForKey = true
+		c.keyRegister = x
 		for index, k := range c.key {
-			if k != 0 {
-				c.V[x] = byte(index)
-				c.pc += 2
+			if k == 0 {
+				continue
+			}
+			if c.quirks.KeyRelease {
+				// Wait for this key to be released
+				c.waitKey = byte(index)
+				c.waitKeyPressed = true
 				break
 			}
+			c.completeKeyWait(byte(index))
+			break
 		}
-		c.key[c.V[x]] = 0
-		result.OpcodeType = "0xFX0A"
-		result.Pseudo = fmt.Sprint("Vx = get_key()")
 	case 0x0015:
 		c.delayTimer = c.V[x]
 		c.pc += 2
-		result.OpcodeType = "0xFX15"
-		result.Pseudo = fmt.Sprintf("delay_timer(V%d)", x)
 
 	case 0x0018:
-		c.soundTimer = c.V[x]
+		c.startSound(c.V[x])
 		c.pc += 2
-		result.OpcodeType = "0xFX18"
-		result.Pseudo = fmt.Sprintf("sound_timer(V%d)", x)
 
 	case 0x001E:
-		c.I += uint16(c.V[x])
+		sum := c.I + uint16(c.V[x])
+		// I addresses 12 bits of memory, so wraps on overflow
+		c.I = sum & 0x0FFF
 		c.pc += 2
-		result.OpcodeType = "0xFX1E"
-		result.Pseudo = fmt.Sprintf("I += V%d", x)
+		if c.quirks.IndexOverflow {
+			var overflow byte
+			if sum > 0x0FFF {
+				overflow = 1
+			}
+			c.setFlag(overflow, "overflow")
+		}
 
 	case 0x0029:
 		// Sets I to the location of the sprite for the character in VX. Characters 0-F (in hexadecimal) are represented by a 4x5 font.
-		c.I = uint16(c.V[x]) * 5
+		// Only the low nibble of VX selects a character.
+		c.I = c.fontAddress + uint16(c.V[x]&0xF)*fontGlyphSize
+		c.pc += 2
+	case 0x0030:
+		// Sets I to the location of the SUPER-CHIP 8x10 sprite for the digit in VX (0-9).
+		// Only the low nibble of VX selects a digit, and digits above 9 use the glyph for 9.
+		digit := uint16(c.V[x] & 0xF)
+		if digit > 9 {
+			digit = 9
+		}
+		c.I = bigFontAddress + digit*10
 		c.pc += 2
-		result.OpcodeType = "0xFX29"
-		result.Pseudo = fmt.Sprintf("I=sprite_addr[V%d]", x)
 	case 0x0033:
-		c.memory[c.I] = c.V[x] / 100
-		c.memory[c.I+1] = (c.V[x] / 10) % 10
-		c.memory[c.I+2] = (c.V[x] % 100) % 10
+		if err := c.checkIndexRange(3); err != nil {
+			return Result{}, err
+		}
+		c.writeMemory(c.I, c.V[x]/100)
+		c.writeMemory(c.I+1, (c.V[x]/10)%10)
+		c.writeMemory(c.I+2, c.V[x]%10)
 		c.pc += 2
-		result.OpcodeType = "0xFX33"
-		result.Pseudo = fmt.Sprintf("set_BCD(V%d);\n*(I + 0) = BCD(3)\n*(I + 1) = BCD(2)\n*(I + 2) = BCD(1)", x)
 	case 0x0055:
+		if err := c.checkIndexRange(int(x) + 1); err != nil {
+			return Result{}, err
+		}
 		for i := uint16(0); i <= x; i++ {
-			c.memory[c.I+i] = c.V[i]
+			c.writeMemory(c.I+i, c.V[i])
 		}
 		c.pc += 2
-		result.OpcodeType = "0xFX55"
-		result.Pseudo = fmt.Sprintf("reg_dump(V%d, &I)", x)
+		if c.quirks.LoadStore {
+			// I wraps to 12 bits, as with FX1E
+			c.I = (c.I + x + 1) & 0x0FFF
+		}
 	case 0x0065:
+		if err := c.checkIndexRange(int(x) + 1); err != nil {
+			return Result{}, err
+		}
 		for i := uint16(0); i <= x; i++ {
 			c.V[i] = c.memory[c.I+i]
 		}
 		c.pc += 2
-		result.OpcodeType = "0xFX65"
-		result.Pseudo = fmt.Sprintf("reg_load(V%d,&I)", x)
+		if c.quirks.LoadStore {
+			// I wraps to 12 bits, as with FX1E
+			c.I = (c.I + x + 1) & 0x0FFF
+		}
+	case 0x0075:
+		n := x
+		if n > 7 {
+			n = 7
+		}
+		for i := uint16(0); i <= n; i++ {
+			c.rpl[i] = c.V[i]
+		}
+		c.pc += 2
+	case 0x0085:
+		n := x
+		if n > 7 {
+			n = 7
+		}
+		for i := uint16(0); i <= n; i++ {
+			c.V[i] = c.rpl[i]
+		}
+		c.pc += 2
 	default:
-		return Result{}, fmt.Errorf("unknown opcode: 0x%X", opcode)
+		return Result{}, ErrUnknownOpcode
 	}
 
-	return result, nil
+	return c.describe(opcode), nil
 }
@@ -1,6 +1,12 @@
 package chip8
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func Test0x00E0(t *testing.T) {
 	cpu := initCPU()
@@ -26,7 +32,7 @@ func Test0x00E0(t *testing.T) {
 
 func Test0x00EE(t *testing.T) {
 	cpu := initCPU()
-	cpu.sp = 0
+	cpu.sp = 1
 	cpu.stack[0] = 0x321
 
 	r, err := cpu.opcode0x0000(0x00EE)
@@ -36,6 +42,153 @@ func Test0x00EE(t *testing.T) {
 	expectOpcodeType(t, r, "0x00EE")
 	// Previous opcode + 2
 	expectPC(t, cpu, 0x321+2)
+	if cpu.sp != 0 {
+		t.Errorf("expected stack to be empty, got sp=%d", cpu.sp)
+	}
+}
+
+func Test0x0000Branches(t *testing.T) {
+	var tests = []struct {
+		opcode     uint16
+		opcodeType string
+		err        error
+	}{
+		{opcode: 0x00E0, opcodeType: "0x00E0"},
+		{opcode: 0x00EE, opcodeType: "0x00EE"},
+		{opcode: 0x00C4, opcodeType: "0x00CN"},
+		{opcode: 0x00FB, opcodeType: "0x00FB"},
+		{opcode: 0x00FF, opcodeType: "0x00FF"},
+		{opcode: 0x00FD, err: ErrUnknownOpcode},
+		{opcode: 0x0000, opcodeType: "0x0NNN"},
+		{opcode: 0x0123, opcodeType: "0x0NNN"},
+		// Only matches 00E0 and 00EE in the low byte
+		{opcode: 0x01E0, opcodeType: "0x0NNN"},
+		{opcode: 0x0FEE, opcodeType: "0x0NNN"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("0x%04X", test.opcode), func(t *testing.T) {
+			cpu := initCPU()
+			cpu.sp = 1
+			cpu.stack[0] = 0x300
+			r, err := cpu.opcode0x0000(test.opcode)
+			if err != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if test.err == nil {
+				expectOpcodeType(t, r, test.opcodeType)
+			}
+		})
+	}
+}
+
+func Test0x0NNN(t *testing.T) {
+	cpu := initCPU()
+	r, err := cpu.opcode0x0000(0x0123)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0x0NNN")
+	if r.Pseudo != "call_machine_code(0x123) ignored" {
+		t.Errorf("unexpected pseudo: %q", r.Pseudo)
+	}
+	expectPC(t, cpu, 0x202)
+}
+
+func TestScroll(t *testing.T) {
+	var tests = []struct {
+		name       string
+		opcode     uint16
+		opcodeType string
+		highRes    bool
+		// Pixel set before scrolling, and where it is expected afterwards
+		before, after [2]int
+		// Pixels that should be cleared by the scroll
+		vacated [][2]int
+	}{
+		{
+			name:       "down",
+			opcode:     0x00C3,
+			opcodeType: "0x00CN",
+			before:     [2]int{5, 10},
+			after:      [2]int{5, 13},
+			vacated:    [][2]int{{0, 0}, {5, 2}, {63, 2}},
+		},
+		{
+			name:       "down high res",
+			opcode:     0x00CF,
+			opcodeType: "0x00CN",
+			highRes:    true,
+			before:     [2]int{100, 40},
+			after:      [2]int{100, 55},
+			vacated:    [][2]int{{0, 0}, {127, 14}},
+		},
+		{
+			name:       "right",
+			opcode:     0x00FB,
+			opcodeType: "0x00FB",
+			before:     [2]int{5, 10},
+			after:      [2]int{9, 10},
+			vacated:    [][2]int{{0, 0}, {3, 10}, {3, 31}},
+		},
+		{
+			name:       "right high res",
+			opcode:     0x00FB,
+			opcodeType: "0x00FB",
+			highRes:    true,
+			before:     [2]int{120, 60},
+			after:      [2]int{124, 60},
+			vacated:    [][2]int{{0, 60}, {3, 63}},
+		},
+		{
+			name:       "left",
+			opcode:     0x00FC,
+			opcodeType: "0x00FC",
+			before:     [2]int{5, 10},
+			after:      [2]int{1, 10},
+			vacated:    [][2]int{{60, 0}, {63, 10}, {63, 31}},
+		},
+		{
+			name:       "left high res",
+			opcode:     0x00FC,
+			opcodeType: "0x00FC",
+			highRes:    true,
+			before:     [2]int{4, 0},
+			after:      [2]int{0, 0},
+			vacated:    [][2]int{{124, 0}, {127, 63}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			if test.highRes {
+				cpu.setResolution(128, 64)
+			}
+			// Fill the display so vacated pixels can be detected
+			for i := range cpu.gfx {
+				cpu.gfx[i] = 1
+			}
+			cpu.gfx[test.before[1]*cpu.width+test.before[0]] = 2
+
+			r, err := cpu.opcode0x0000(test.opcode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, test.opcodeType)
+			expectPC(t, cpu, 0x202)
+
+			if p := cpu.gfx[test.after[1]*cpu.width+test.after[0]]; p != 2 {
+				t.Errorf("expected pixel at %v to be 2, got %d", test.after, p)
+			}
+			for _, v := range test.vacated {
+				if p := cpu.gfx[v[1]*cpu.width+v[0]]; p != 0 {
+					t.Errorf("expected pixel at %v to be cleared, got %d", v, p)
+				}
+			}
+			if !cpu.DrawFlag() {
+				t.Errorf("expected draw flag to be set")
+			}
+		})
+	}
 }
 
 func Test0x1NNN(t *testing.T) {
@@ -195,6 +348,24 @@ func Test0x8XY3(t *testing.T) {
 	expectRegister(t, cpu, 1, 0x1F)
 }
 
+func TestVFResetQuirk(t *testing.T) {
+	for _, opcode := range []uint16{0x8011, 0x8012, 0x8013} {
+		t.Run(fmt.Sprintf("0x%04X", opcode), func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks.VFReset = true
+			cpu.V[0xF] = 1
+			r, err := cpu.opcode0x8000(opcode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectRegister(t, cpu, 0xF, 0)
+			if !strings.HasSuffix(r.Pseudo, "; VF = 0") {
+				t.Errorf("expected pseudo to show VF reset, got %q", r.Pseudo)
+			}
+		})
+	}
+}
+
 func Test0x8XY4(t *testing.T) {
 	var tests = []struct {
 		name       string
@@ -264,6 +435,14 @@ func Test0x8XY5(t *testing.T) {
 			expectedV1: 0x02,
 			expectedVF: 0,
 		},
+		{
+			name:       "equal",
+			v0:         0x07,
+			v1:         0x07,
+			expectedV0: 0x00,
+			expectedV1: 0x07,
+			expectedVF: 1,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -286,6 +465,7 @@ func Test0x8XY5(t *testing.T) {
 func Test0x8XY6(t *testing.T) {
 	var tests = []struct {
 		name       string
+		quirks     Quirks
 		v0         byte
 		v1         byte
 		expectedV0 byte
@@ -308,10 +488,29 @@ func Test0x8XY6(t *testing.T) {
 			expectedV1: 0x03,
 			expectedVF: 1,
 		},
+		{
+			name:       "shift quirk, least significant bit of 0",
+			quirks:     Quirks{Shift: true},
+			v0:         0x08,
+			v1:         0x03,
+			expectedV0: 0x04,
+			expectedV1: 0x03,
+			expectedVF: 0,
+		},
+		{
+			name:       "shift quirk, least significant bit of 1",
+			quirks:     Quirks{Shift: true},
+			v0:         0x09,
+			v1:         0x02,
+			expectedV0: 0x04,
+			expectedV1: 0x02,
+			expectedVF: 1,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			cpu := initCPU()
+			cpu.quirks = test.quirks
 			cpu.V[0] = test.v0
 			cpu.V[1] = test.v1
 			r, err := cpu.opcode0x8000(0x8016)
@@ -327,50 +526,1106 @@ func Test0x8XY6(t *testing.T) {
 	}
 }
 
-func Test0xFX18(t *testing.T) {
-	cpu := initCPU()
-	cpu.V[0] = 0x0F
-	r, err := cpu.opcode0xF000(0xF018)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+func Test0x8XYE(t *testing.T) {
+	var tests = []struct {
+		name       string
+		quirks     Quirks
+		v0         byte
+		v1         byte
+		expectedV0 byte
+		expectedV1 byte
+		expectedVF byte
+	}{
+		{
+			name:       "most significant bit of 0",
+			v0:         0x00,
+			v1:         0x41,
+			expectedV0: 0x82,
+			expectedV1: 0x41,
+			expectedVF: 0,
+		},
+		{
+			name:       "most significant bit of 1",
+			v0:         0x00,
+			v1:         0x81,
+			expectedV0: 0x02,
+			expectedV1: 0x81,
+			expectedVF: 1,
+		},
+		{
+			name:       "all bits set",
+			v0:         0x00,
+			v1:         0xFF,
+			expectedV0: 0xFE,
+			expectedV1: 0xFF,
+			expectedVF: 1,
+		},
+		{
+			name:       "shift quirk, most significant bit of 0",
+			quirks:     Quirks{Shift: true},
+			v0:         0x21,
+			v1:         0x81,
+			expectedV0: 0x42,
+			expectedV1: 0x81,
+			expectedVF: 0,
+		},
+		{
+			name:       "shift quirk, most significant bit of 1",
+			quirks:     Quirks{Shift: true},
+			v0:         0xC0,
+			v1:         0x01,
+			expectedV0: 0x80,
+			expectedV1: 0x01,
+			expectedVF: 1,
+		},
 	}
-	expectOpcodeType(t, r, "0xFX18")
-	if cpu.soundTimer != 0x0F {
-		t.Errorf("Expected sountTimer to be 0x0F, got 0x%X", cpu.soundTimer)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks = test.quirks
+			cpu.V[0] = test.v0
+			cpu.V[1] = test.v1
+			r, err := cpu.opcode0x8000(0x801E)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0x8XYE")
+
+			expectRegister(t, cpu, 0, test.expectedV0)
+			expectRegister(t, cpu, 1, test.expectedV1)
+			expectRegister(t, cpu, 0xF, test.expectedVF)
+		})
 	}
 }
 
-func initCPU() *Chip8 {
-	cpu := &Chip8{}
-	cpu.initialize()
-	return cpu
+func TestShiftQuirkOption(t *testing.T) {
+	// V0 = 0x81; V1 = 0x42; V0 >>= 1 or V0 = V1 >> 1; V0 <<= 1 or V0 = V1 << 1
+	rom := BuildROM(0x6081, 0x6142, 0x8016, 0x801E)
+	var tests = []struct {
+		name string
+		opts []Option
+		// V0 and VF after each shift
+		expected [2][2]byte
+	}{
+		{
+			name:     "default",
+			expected: [2][2]byte{{0x21, 0}, {0x84, 0}},
+		},
+		{
+			name:     "shift quirk",
+			opts:     []Option{WithQuirks(Quirks{Shift: true})},
+			expected: [2][2]byte{{0x40, 1}, {0x80, 0}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, err := New(bytes.NewReader(rom), test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := cpu.EmulateCycle(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			for _, expected := range test.expected {
+				if _, err := cpu.EmulateCycle(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				expectRegister(t, cpu, 0, expected[0])
+				expectRegister(t, cpu, 0xF, expected[1])
+			}
+			expectRegister(t, cpu, 1, 0x42)
+		})
+	}
 }
 
-func expectRegister(t *testing.T, cpu *Chip8, register int, expected byte) {
-	t.Helper()
-	if cpu.V[register] != expected {
-		t.Errorf("V%d should be 0x%X, got 0x%X", register, expected, cpu.V[register])
+func TestShiftQuirkPseudo(t *testing.T) {
+	var tests = []struct {
+		opcode   uint16
+		quirks   Quirks
+		expected string
+	}{
+		{opcode: 0x8126, expected: "V1=V2=V2>>1"},
+		{opcode: 0x8126, quirks: Quirks{Shift: true}, expected: "V1>>=1"},
+		{opcode: 0x812E, expected: "V1=V2=V2<<1"},
+		{opcode: 0x812E, quirks: Quirks{Shift: true}, expected: "V1<<=1"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("0x%04X %+v", test.opcode, test.quirks), func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks = test.quirks
+			r, err := cpu.opcode0x8000(test.opcode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.Pseudo != test.expected {
+				t.Errorf("expected pseudo %q, got %q", test.expected, r.Pseudo)
+			}
+		})
 	}
 }
 
-func expectPC(t *testing.T, cpu *Chip8, expected uint16) {
-	t.Helper()
-	if cpu.pc != expected {
-		t.Errorf("PC should be 0x%X, got 0x%X", expected, cpu.pc)
+func Test0x8XY7(t *testing.T) {
+	var tests = []struct {
+		name       string
+		v0         byte
+		v1         byte
+		expectedV0 byte
+		expectedV1 byte
+		expectedVF byte
+	}{
+		{
+			name:       "no borrow",
+			v0:         0x02,
+			v1:         0x05,
+			expectedV0: 0x03,
+			expectedV1: 0x05,
+			expectedVF: 1,
+		},
+		{
+			name:       "borrow",
+			v0:         0x02,
+			v1:         0x01,
+			expectedV0: 0xFF,
+			expectedV1: 0x01,
+			expectedVF: 0,
+		},
+		{
+			name:       "equal",
+			v0:         0x07,
+			v1:         0x07,
+			expectedV0: 0x00,
+			expectedV1: 0x07,
+			expectedVF: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.V[0] = test.v0
+			cpu.V[1] = test.v1
+			r, err := cpu.opcode0x8000(0x8017)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0x8XY7")
+
+			expectRegister(t, cpu, 0, test.expectedV0)
+			expectRegister(t, cpu, 1, test.expectedV1)
+			expectRegister(t, cpu, 0xF, test.expectedVF)
+		})
 	}
 }
 
-// expectStack tests for a particular value on the top of the stack
-func expectStack(t *testing.T, cpu *Chip8, expected uint16) {
-	t.Helper()
-	if cpu.stack[cpu.sp] != expected {
-		t.Errorf("Top of stack should be 0x%X, got 0x%X", expected, cpu.stack[cpu.sp])
+func Test0xBNNN(t *testing.T) {
+	var tests = []struct {
+		name       string
+		quirks     Quirks
+		opcodeType string
+		expectedPC uint16
+	}{
+		{
+			name:       "V0 offset",
+			opcodeType: "0xBNNN",
+			expectedPC: 0x345 + 0x10,
+		},
+		{
+			name:       "jump quirk",
+			quirks:     Quirks{Jump: true},
+			opcodeType: "0xBXNN",
+			expectedPC: 0x345 + 0x20,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks = test.quirks
+			cpu.V[0] = 0x10
+			cpu.V[3] = 0x20
+			r, err := cpu.opcode0xB000(0xB345)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, test.opcodeType)
+			expectPC(t, cpu, test.expectedPC)
+		})
 	}
 }
 
-func expectOpcodeType(t *testing.T, r Result, expected string) {
-	if r.OpcodeType != expected {
-		t.Logf("%+v", r)
-		t.Errorf("Expected opcode type %s, got %s", expected, r.OpcodeType)
+func Test0xDXYN(t *testing.T) {
+	var tests = []struct {
+		name   string
+		quirks Quirks
+		x, y   byte
+		// Pixels expected to be on after drawing
+		expected [][2]int
+	}{
+		{
+			name: "inside display",
+			x:    10,
+			y:    5,
+			expected: [][2]int{
+				{10, 5}, {11, 5}, {12, 5}, {13, 5}, {14, 5}, {15, 5}, {16, 5}, {17, 5},
+				{10, 6}, {11, 6}, {12, 6}, {13, 6}, {14, 6}, {15, 6}, {16, 6}, {17, 6},
+			},
+		},
+		{
+			name: "start position wraps",
+			x:    64 + 10,
+			y:    32 + 5,
+			expected: [][2]int{
+				{10, 5}, {11, 5}, {12, 5}, {13, 5}, {14, 5}, {15, 5}, {16, 5}, {17, 5},
+				{10, 6}, {11, 6}, {12, 6}, {13, 6}, {14, 6}, {15, 6}, {16, 6}, {17, 6},
+			},
+		},
+		{
+			name: "clipped at right and bottom edges",
+			x:    60,
+			y:    31,
+			expected: [][2]int{
+				{60, 31}, {61, 31}, {62, 31}, {63, 31},
+			},
+		},
+		{
+			name:   "wrapped at right and bottom edges",
+			quirks: Quirks{DrawWrap: true},
+			x:      60,
+			y:      31,
+			expected: [][2]int{
+				{60, 31}, {61, 31}, {62, 31}, {63, 31}, {0, 31}, {1, 31}, {2, 31}, {3, 31},
+				{60, 0}, {61, 0}, {62, 0}, {63, 0}, {0, 0}, {1, 0}, {2, 0}, {3, 0},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks = test.quirks
+			cpu.V[0] = test.x
+			cpu.V[1] = test.y
+			// Two rows of 8 pixels
+			cpu.I = 0x300
+			cpu.memory[0x300] = 0xFF
+			cpu.memory[0x301] = 0xFF
+
+			r, err := cpu.opcode0xD000(0xD012)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0xDXYN")
+			expectPC(t, cpu, 0x202)
+
+			expected := make([]byte, len(cpu.gfx))
+			for _, p := range test.expected {
+				expected[p[1]*cpu.width+p[0]] = 1
+			}
+			if !bytes.Equal(cpu.gfx, expected) {
+				for i := range expected {
+					if cpu.gfx[i] != expected[i] {
+						t.Errorf("pixel (%d,%d) expected %d, got %d", i%cpu.width, i/cpu.width, expected[i], cpu.gfx[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test0xDXYNBottomRightCorner(t *testing.T) {
+	for _, wrap := range []bool{false, true} {
+		t.Run(fmt.Sprintf("wrap=%v", wrap), func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks.DrawWrap = wrap
+			// Font sprite for "0"
+			cpu.I = defaultFontAddress
+			cpu.V[0] = 63
+			cpu.V[1] = 31
+
+			if _, err := cpu.opcode0xD000(0xD015); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectRegister(t, cpu, 0xF, 0)
+			if cpu.gfx[len(cpu.gfx)-1] != 1 {
+				t.Errorf("expected bottom right pixel to be on")
+			}
+
+			// Drawing again erases the sprite
+			cpu.pc = 0x200
+			if _, err := cpu.opcode0xD000(0xD015); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectRegister(t, cpu, 0xF, 1)
+			for i, p := range cpu.gfx {
+				if p != 0 {
+					t.Errorf("expected pixel (%d,%d) to be off", i%64, i/64)
+				}
+			}
+		})
+	}
+}
+
+func Test0xDXYNRightEdge(t *testing.T) {
+	cpu := initCPU()
+	cpu.I = 0x300
+	cpu.memory[0x300] = 0xFF
+	cpu.V[0] = 62
+	cpu.V[1] = 5
+
+	if _, err := cpu.opcode0xD000(0xD011); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 0xF, 0)
+	for i, p := range cpu.gfx {
+		expected := byte(0)
+		if i == 5*64+62 || i == 5*64+63 {
+			expected = 1
+		}
+		if p != expected {
+			t.Errorf("pixel (%d,%d) expected %d, got %d", i%64, i/64, expected, p)
+		}
+	}
+}
+
+func TestDrawWrapQuirk(t *testing.T) {
+	// An 8x4 sprite with a distinct pattern on each row
+	sprite := []byte{0x81, 0xC3, 0xE7, 0xFF}
+	var tests = []struct {
+		name string
+		wrap bool
+	}{
+		{name: "clip"},
+		{name: "wrap", wrap: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks.DrawWrap = test.wrap
+			if err := cpu.LoadSprite(0x300, sprite); err != nil {
+				t.Fatal(err)
+			}
+			cpu.V[0] = 62
+			cpu.V[1] = 30
+			if _, err := cpu.opcode0xD000(0xD014); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := make([]byte, len(cpu.gfx))
+			for row, bits := range sprite {
+				for col := 0; col < 8; col++ {
+					if bits&(0x80>>uint(col)) == 0 {
+						continue
+					}
+					x, y := 62+col, 30+row
+					if x >= 64 || y >= 32 {
+						if !test.wrap {
+							continue
+						}
+						x, y = x%64, y%32
+					}
+					expected[y*64+x] = 1
+				}
+			}
+			for i := range expected {
+				if cpu.gfx[i] != expected[i] {
+					t.Errorf("pixel (%d,%d) expected %d, got %d", i%64, i/64, expected[i], cpu.gfx[i])
+				}
+			}
+
+			// Only the rows covered by the sprite are drawn on
+			for y := 0; y < 32; y++ {
+				covered := y == 30 || y == 31 || (test.wrap && (y == 0 || y == 1))
+				if covered {
+					continue
+				}
+				for x := 0; x < 64; x++ {
+					if cpu.gfx[y*64+x] != 0 {
+						t.Errorf("unexpected pixel on row %d at column %d", y, x)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test0xEX9E(t *testing.T) {
+	var tests = []struct {
+		name       string
+		down       bool
+		expectedPC uint16
+	}{
+		{
+			name:       "key down",
+			down:       true,
+			expectedPC: 0x204,
+		},
+		{
+			name:       "key up",
+			expectedPC: 0x202,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.V[2] = 0xC
+			if test.down {
+				cpu.SetKeyDown(0xC)
+			}
+			r, err := cpu.opcode0xE000(0xE29E)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0xEX9E")
+			expectPC(t, cpu, test.expectedPC)
+			if cpu.GetKeys()[0xC] != test.down {
+				t.Errorf("expected key state to be unchanged")
+			}
+		})
+	}
+}
+
+func Test0xEXA1(t *testing.T) {
+	var tests = []struct {
+		name       string
+		down       bool
+		expectedPC uint16
+	}{
+		{
+			name:       "key down",
+			down:       true,
+			expectedPC: 0x202,
+		},
+		{
+			name:       "key up",
+			expectedPC: 0x204,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.V[2] = 0xC
+			if test.down {
+				cpu.SetKeyDown(0xC)
+			}
+			r, err := cpu.opcode0xE000(0xE2A1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0xEXA1")
+			expectPC(t, cpu, test.expectedPC)
+			if cpu.GetKeys()[0xC] != test.down {
+				t.Errorf("expected key state to be unchanged")
+			}
+		})
+	}
+}
+
+func Test0xFX18(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[0] = 0x0F
+	r, err := cpu.opcode0xF000(0xF018)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0xFX18")
+	if cpu.soundTimer != 0x0F {
+		t.Errorf("Expected sountTimer to be 0x0F, got 0x%X", cpu.soundTimer)
+	}
+}
+
+func Test0xFX33(t *testing.T) {
+	var tests = []struct {
+		value    byte
+		expected [3]byte
+	}{
+		{value: 0, expected: [3]byte{0, 0, 0}},
+		{value: 9, expected: [3]byte{0, 0, 9}},
+		{value: 10, expected: [3]byte{0, 1, 0}},
+		{value: 99, expected: [3]byte{0, 9, 9}},
+		{value: 100, expected: [3]byte{1, 0, 0}},
+		{value: 255, expected: [3]byte{2, 5, 5}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.value), func(t *testing.T) {
+			cpu := initCPU()
+			cpu.I = 0x300
+			cpu.V[2] = test.value
+			r, err := cpu.opcode0xF000(0xF233)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0xFX33")
+			for i, digit := range test.expected {
+				if cpu.memory[0x300+i] != digit {
+					t.Errorf("memory[I+%d] should be %d, got %d", i, digit, cpu.memory[0x300+i])
+				}
+			}
+			expectPC(t, cpu, 0x202)
+		})
+	}
+}
+
+func Test0xFX30(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[2] = 7
+	r, err := cpu.opcode0xF000(0xF230)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0xFX30")
+	expectPC(t, cpu, 0x202)
+	if expected := uint16(bigFontAddress + 70); cpu.I != expected {
+		t.Fatalf("expected I to be 0x%X, got 0x%X", expected, cpu.I)
+	}
+
+	// The glyph is 10 rows high
+	cpu.V[0], cpu.V[1] = 0, 0
+	if _, err := cpu.opcode0xD000(0xD01A); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	glyph := superChipBigFontset[70:80]
+	for y, row := range glyph {
+		for x := 0; x < 8; x++ {
+			expected := row >> (7 - x) & 1
+			if got := cpu.gfx[y*cpu.width+x]; got != expected {
+				t.Errorf("pixel (%d,%d): expected %d, got %d", x, y, expected, got)
+			}
+		}
+	}
+
+	// Only the low nibble selects a digit, and there are no glyphs above 9
+	for _, test := range []struct {
+		value byte
+		digit uint16
+	}{
+		{value: 0x13, digit: 3},
+		{value: 0x0C, digit: 9},
+		{value: 0xFF, digit: 9},
+	} {
+		cpu.V[2] = test.value
+		if _, err := cpu.opcode0xF000(0xF230); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := uint16(bigFontAddress) + test.digit*10; cpu.I != expected {
+			t.Errorf("V2 = 0x%02X: expected I to be 0x%X, got 0x%X", test.value, expected, cpu.I)
+		}
+	}
+}
+
+func Test0xFX29(t *testing.T) {
+	cpu := initCPU()
+	for _, test := range []struct {
+		value byte
+		digit uint16
+	}{
+		{value: 0x0A, digit: 0xA},
+		{value: 0x1A, digit: 0xA},
+		{value: 0xFF, digit: 0xF},
+	} {
+		cpu.V[1] = test.value
+		r, err := cpu.opcode0xF000(0xF129)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectOpcodeType(t, r, "0xFX29")
+		if expected := defaultFontAddress + test.digit*fontGlyphSize; cpu.I != expected {
+			t.Errorf("V1 = 0x%02X: expected I to be 0x%X, got 0x%X", test.value, expected, cpu.I)
+		}
+	}
+}
+
+func Test0xDXY0(t *testing.T) {
+	cpu := initCPU()
+	// A 16x16 sprite with a different pattern in each half of every row
+	cpu.I = 0x300
+	for row := 0; row < 16; row++ {
+		cpu.memory[0x300+2*row] = byte(row)
+		cpu.memory[0x300+2*row+1] = 0x80 | byte(row)
+	}
+	cpu.V[0], cpu.V[1] = 4, 2
+	r, err := cpu.opcode0xD000(0xD010)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0xDXYN")
+	expectRegister(t, cpu, 0xF, 0)
+	for row := 0; row < 16; row++ {
+		bits := uint16(cpu.memory[0x300+2*row])<<8 | uint16(cpu.memory[0x300+2*row+1])
+		for col := 0; col < 16; col++ {
+			expected := byte(bits >> (15 - col) & 1)
+			if got := cpu.gfx[(2+row)*cpu.width+4+col]; got != expected {
+				t.Errorf("pixel (%d,%d): expected %d, got %d", col, row, expected, got)
+			}
+		}
+	}
+
+	// Drawing it again erases it, with a collision
+	if _, err := cpu.opcode0xD000(0xD010); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 0xF, 1)
+	for i, p := range cpu.gfx {
+		if p != 0 {
+			t.Fatalf("pixel %d should be erased, got %d", i, p)
+		}
+	}
+
+	// All 32 bytes of the sprite must be in memory
+	cpu.I = 0xFE0
+	if _, err := cpu.opcode0xD000(0xD010); err != nil {
+		t.Errorf("unexpected error at the top of memory: %v", err)
+	}
+	cpu.I = 0xFE1
+	if _, err := cpu.opcode0xD000(0xD010); !errors.Is(err, ErrMemoryOutOfRange) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+}
+
+func initCPU() *Chip8 {
+	cpu := &Chip8{}
+	cpu.initialize()
+	// Describe every cycle, so that Results can be checked
+	cpu.fullResults = true
+	return cpu
+}
+
+func expectRegister(t *testing.T, cpu *Chip8, register int, expected byte) {
+	t.Helper()
+	if cpu.V[register] != expected {
+		t.Errorf("V%d should be 0x%X, got 0x%X", register, expected, cpu.V[register])
+	}
+}
+
+func expectPC(t *testing.T, cpu *Chip8, expected uint16) {
+	t.Helper()
+	if cpu.pc != expected {
+		t.Errorf("PC should be 0x%X, got 0x%X", expected, cpu.pc)
+	}
+}
+
+// expectStack tests for a particular value on the top of the stack
+func expectStack(t *testing.T, cpu *Chip8, expected uint16) {
+	t.Helper()
+	if cpu.sp == 0 || cpu.stack[cpu.sp-1] != expected {
+		t.Errorf("Top of stack should be 0x%X, got %v", expected, cpu.stack[:cpu.sp])
+	}
+}
+
+func expectOpcodeType(t *testing.T, r Result, expected string) {
+	if r.OpcodeType != expected {
+		t.Logf("%+v", r)
+		t.Errorf("Expected opcode type %s, got %s", expected, r.OpcodeType)
+	}
+}
+
+func TestLoadStoreQuirk(t *testing.T) {
+	var tests = []struct {
+		name           string
+		opcode         uint16
+		loadStore      bool
+		expectedI      uint16
+		expectedPseudo string
+	}{
+		{
+			name:           "FX55",
+			opcode:         0xF355,
+			expectedI:      0x300,
+			expectedPseudo: "reg_dump(V3, &I)",
+		},
+		{
+			name:           "FX55 load/store quirk",
+			opcode:         0xF355,
+			loadStore:      true,
+			expectedI:      0x304,
+			expectedPseudo: "reg_dump(V3, &I); I += 4",
+		},
+		{
+			name:           "FX65",
+			opcode:         0xF365,
+			expectedI:      0x300,
+			expectedPseudo: "reg_load(V3,&I)",
+		},
+		{
+			name:           "FX65 load/store quirk",
+			opcode:         0xF365,
+			loadStore:      true,
+			expectedI:      0x304,
+			expectedPseudo: "reg_load(V3,&I); I += 4",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks.LoadStore = test.loadStore
+			cpu.I = 0x300
+			values := []byte{0x11, 0x22, 0x33, 0x44, 0x55}
+			// Registers are stored to memory, or loaded from it
+			if test.opcode&0x00FF == 0x0055 {
+				copy(cpu.V[:], values)
+			} else {
+				copy(cpu.memory[0x300:], values)
+			}
+
+			r, err := cpu.opcode0xF000(test.opcode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cpu.I != test.expectedI {
+				t.Errorf("expected I to be 0x%X, got 0x%X", test.expectedI, cpu.I)
+			}
+			if r.Pseudo != test.expectedPseudo {
+				t.Errorf("expected pseudo %q, got %q", test.expectedPseudo, r.Pseudo)
+			}
+
+			// Only V0-V3 are copied
+			if !bytes.Equal(cpu.memory[0x300:0x304], values[:4]) || !bytes.Equal(cpu.V[:4], values[:4]) {
+				t.Errorf("expected V0-V3 and memory to match, got % X and % X", cpu.V[:4], cpu.memory[0x300:0x304])
+			}
+			if cpu.V[4] != 0 && cpu.memory[0x304] != 0 {
+				t.Errorf("expected V4 not to be copied")
+			}
+		})
+	}
+
+	t.Run("top of memory", func(t *testing.T) {
+		for _, opcode := range []uint16{0xFF55, 0xFF65} {
+			cpu := initCPU()
+			cpu.quirks.LoadStore = true
+			cpu.I = 0xFF0
+			if _, err := cpu.opcode0xF000(opcode); err != nil {
+				t.Fatalf("0x%04X: unexpected error: %v", opcode, err)
+			}
+			if cpu.I != 0x000 {
+				t.Errorf("0x%04X: expected I to wrap to 0x000, got 0x%X", opcode, cpu.I)
+			}
+		}
+	})
+}
+
+func TestIndexOverflowQuirk(t *testing.T) {
+	var tests = []struct {
+		name           string
+		indexOverflow  bool
+		initialVF      byte
+		expectedVF     byte
+		expectedPseudo string
+	}{
+		{
+			name:           "default",
+			initialVF:      0xAA,
+			expectedVF:     0xAA,
+			expectedPseudo: "I += V3",
+		},
+		{
+			name:           "index overflow quirk",
+			indexOverflow:  true,
+			initialVF:      0xAA,
+			expectedVF:     1,
+			expectedPseudo: "I += V3; VF = overflow",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.quirks.IndexOverflow = test.indexOverflow
+			cpu.I = 0xFFF
+			cpu.V[3] = 0x10
+			cpu.V[0xF] = test.initialVF
+
+			r, err := cpu.opcode0xF000(0xF31E)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cpu.I != 0x00F {
+				t.Errorf("expected I to wrap to 0x00F, got 0x%X", cpu.I)
+			}
+			expectRegister(t, cpu, 0xF, test.expectedVF)
+			if r.Pseudo != test.expectedPseudo {
+				t.Errorf("expected pseudo %q, got %q", test.expectedPseudo, r.Pseudo)
+			}
+		})
+	}
+
+	t.Run("no overflow clears VF", func(t *testing.T) {
+		cpu := initCPU()
+		cpu.quirks.IndexOverflow = true
+		cpu.I = 0xFEF
+		cpu.V[3] = 0x10
+		cpu.V[0xF] = 1
+
+		if _, err := cpu.opcode0xF000(0xF31E); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cpu.I != 0xFFF {
+			t.Errorf("expected I to be 0xFFF, got 0x%X", cpu.I)
+		}
+		expectRegister(t, cpu, 0xF, 0)
+	})
+}
+
+func Test0xFX0A(t *testing.T) {
+	t.Run("key already down", func(t *testing.T) {
+		cpu := initCPU()
+		cpu.SetKeyDown(0x7)
+		r, err := cpu.opcode0xF000(0xF30A)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectOpcodeType(t, r, "0xFX0A")
+		expectRegister(t, cpu, 3, 0x7)
+		expectPC(t, cpu, 0x202)
+		if !cpu.GetKeys()[0x7] {
+			t.Errorf("expected key to remain held")
+		}
+	})
+
+	t.Run("no key down", func(t *testing.T) {
+		cpu := initCPU()
+		cpu.V[3] = 0xEE
+		r, err := cpu.opcode0xF000(0xF30A)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectOpcodeType(t, r, "0xFX0A")
+		expectRegister(t, cpu, 3, 0xEE)
+		expectPC(t, cpu, 0x200)
+		if !cpu.waitingForKey {
+			t.Errorf("expected to be waiting for a key")
+		}
+	})
+
+	t.Run("waits for key", func(t *testing.T) {
+		// V3 = get_key(); V4 = 1
+		cpu, err := New(bytes.NewReader(BuildROM(0xF30A, 0x6401)), WithFullResults())
+		if err != nil {
+			t.Fatal(err)
+		}
+		cpu.V[3] = 0xEE
+
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectOpcodeType(t, r, "0xFX0A")
+		for i := 0; i < 5; i++ {
+			r, err := cpu.EmulateCycle()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0xFX0A (waiting)")
+			if r.Pseudo != "V3 = get_key()" {
+				t.Errorf("unexpected pseudo: %q", r.Pseudo)
+			}
+			expectPC(t, cpu, 0x200)
+			expectRegister(t, cpu, 3, 0xEE)
+			expectRegister(t, cpu, 4, 0)
+		}
+
+		cpu.SetKeyDown(0xA)
+		expectRegister(t, cpu, 3, 0xA)
+		expectPC(t, cpu, 0x202)
+		if !cpu.GetKeys()[0xA] {
+			t.Errorf("expected key to be held")
+		}
+
+		r, err = cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectOpcodeType(t, r, "0x6XNN")
+		expectRegister(t, cpu, 4, 1)
+
+		// Further presses are not stored
+		cpu.SetKeyDown(0xB)
+		expectRegister(t, cpu, 3, 0xA)
+	})
+
+	t.Run("timers run while waiting", func(t *testing.T) {
+		clock := &ManualClock{}
+		// V3 = get_key(); V4 = 1
+		cpu, err := New(bytes.NewReader(BuildROM(0xF30A, 0x6401)), WithClock(clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cpu.delayTimer = 10
+
+		for i := 0; i < 4; i++ {
+			clock.Advance(timerPeriod)
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		expectPC(t, cpu, 0x200)
+		if cpu.delayTimer != 6 {
+			t.Errorf("expected delay timer to count down to 6, got %d", cpu.delayTimer)
+		}
+	})
+
+	t.Run("key release quirk", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(BuildROM(0xF30A, 0x6401)), WithQuirks(Quirks{KeyRelease: true}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Key held from before the instruction
+		cpu.SetKeyDown(0x5)
+
+		for i := 0; i < 5; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectPC(t, cpu, 0x200)
+			expectRegister(t, cpu, 3, 0)
+		}
+
+		// Other keys don't complete the wait
+		cpu.SetKeyDown(0x6)
+		cpu.SetKeyUp(0x6)
+		expectPC(t, cpu, 0x200)
+
+		cpu.SetKeyUp(0x5)
+		expectRegister(t, cpu, 3, 0x5)
+		expectPC(t, cpu, 0x202)
+	})
+}
+
+func Test0xFN01(t *testing.T) {
+	var tests = []struct {
+		name   string
+		planes uint16
+		// Expected pixel at (0,0) on each plane after drawing
+		expected [2]byte
+	}{
+		{
+			name:     "no planes",
+			planes:   0,
+			expected: [2]byte{0, 0},
+		},
+		{
+			name:     "first plane",
+			planes:   1,
+			expected: [2]byte{1, 0},
+		},
+		{
+			name:     "second plane",
+			planes:   2,
+			expected: [2]byte{0, 1},
+		},
+		{
+			name:     "both planes",
+			planes:   3,
+			expected: [2]byte{1, 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			// One row sprites: the first lights the leftmost pixel, the second the next pixel
+			cpu.I = 0x300
+			cpu.memory[0x300] = 0x80
+			cpu.memory[0x301] = 0x40
+
+			r, err := cpu.opcode0xF000(0xF001 | test.planes<<8)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expectOpcodeType(t, r, "0xFN01")
+			expectPC(t, cpu, 0x202)
+
+			if _, err := cpu.opcode0xD000(0xD001); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			planes := cpu.GetGraphicsPlanes()
+			for i, expected := range test.expected {
+				if planes[i][0] != expected {
+					t.Errorf("expected plane %d pixel to be %d, got %d", i+1, expected, planes[i][0])
+				}
+			}
+			if test.planes == 3 && planes[1][1] != 1 {
+				t.Errorf("expected second plane to be drawn with the second sprite")
+			}
+		})
+	}
+}
+
+func Test0xFN01InvalidPlanes(t *testing.T) {
+	cpu := initCPU()
+	if _, err := cpu.opcode0xF000(0xF401); !errors.Is(err, ErrUnknownOpcode) {
+		t.Errorf("expected ErrUnknownOpcode, got %v", err)
+	}
+	if cpu.planeMask != 0x1 {
+		t.Errorf("expected plane mask to be unchanged, got %d", cpu.planeMask)
+	}
+	if err := cpu.Restore(cpu.Snapshot()); err != nil {
+		t.Errorf("unexpected error restoring state: %v", err)
+	}
+}
+
+func TestPlanesCombineColors(t *testing.T) {
+	cpu := initCPU()
+	cpu.I = 0x300
+	cpu.memory[0x300] = 0xC0
+	cpu.memory[0x301] = 0xA0
+
+	// Draw to both planes, so pixels have colors 3, 1 and 2
+	cpu.planeMask = 0x3
+	if _, err := cpu.opcode0xD000(0xD001); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buffer := cpu.GetGraphicsBuffer()
+	if !bytes.Equal(buffer[:3], []byte{3, 1, 2}) {
+		t.Errorf("expected colors [3 1 2], got %v", buffer[:3])
+	}
+
+	// Drawing again on the second plane alone collides and leaves the first plane
+	cpu.planeMask = 0x2
+	cpu.pc = 0x200
+	if _, err := cpu.opcode0xD000(0xD001); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 0xF, 1)
+	if buffer := cpu.GetGraphicsBuffer(); !bytes.Equal(buffer[:3], []byte{1, 3, 2}) {
+		t.Errorf("expected colors [1 3 2], got %v", buffer[:3])
+	}
+
+	// Clearing only affects the selected planes
+	if _, err := cpu.opcode0x0000(0x00E0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buffer := cpu.GetGraphicsBuffer(); !bytes.Equal(buffer[:3], []byte{1, 1, 0}) {
+		t.Errorf("expected colors [1 1 0], got %v", buffer[:3])
+	}
+}
+
+func TestRPLFlags(t *testing.T) {
+	cpu := initCPU()
+	values := []byte{0x10, 0x21, 0x32, 0x43, 0x54, 0x65, 0x76, 0x87, 0x98}
+	copy(cpu.V[:], values)
+
+	// X above 7 is clamped, so V8 is not saved
+	r, err := cpu.opcode0xF000(0xF875)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0xFX75")
+	expectPC(t, cpu, 0x202)
+
+	cpu.V = [16]byte{}
+	r, err = cpu.opcode0xF000(0xFF85)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0xFX85")
+	expectPC(t, cpu, 0x204)
+
+	if !bytes.Equal(cpu.V[:8], values[:8]) {
+		t.Errorf("expected V0-V7 to be restored as % X, got % X", values[:8], cpu.V[:8])
+	}
+	expectRegister(t, cpu, 8, 0)
+
+	// Only V0-VX are restored
+	cpu.V = [16]byte{}
+	if _, err := cpu.opcode0xF000(0xF185); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
+	expectRegister(t, cpu, 1, 0x21)
+	expectRegister(t, cpu, 2, 0)
 }
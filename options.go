@@ -0,0 +1,56 @@
+package chip8
+
+import "net/http"
+
+// defaultLoadAddress is where ROMs are loaded and executed from unless configured otherwise.
+const defaultLoadAddress = 0x200
+
+// Option configures optional behavior of a Chip8 at creation time.
+// Options are passed to New or NewFromURL.
+type Option func(*Chip8)
+
+// WithHTTPClient sets the client used by NewFromURL to fetch a ROM.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Chip8) {
+		c.httpClient = client
+	}
+}
+
+// WithLoadAddress sets the address in memory that the ROM is loaded at, such
+// as 0x600 for ETI-660 programs. Unless WithEntryPoint is also provided,
+// execution starts at the load address.
+// Creating the machine fails if the ROM would not fit in memory at this address.
+func WithLoadAddress(addr uint16) Option {
+	return func(c *Chip8) {
+		c.loadAddress = addr
+	}
+}
+
+// WithEntryPoint sets the address that execution starts from.
+func WithEntryPoint(addr uint16) Option {
+	return func(c *Chip8) {
+		c.entryPoint = addr
+	}
+}
+
+// ProgramStart returns the address the ROM is loaded at. Memory below it is
+// reserved for the interpreter, such as the fonts.
+func (c *Chip8) ProgramStart() uint16 {
+	return c.loadAddress
+}
+
+// startAddress returns the address execution starts from.
+func (c *Chip8) startAddress() uint16 {
+	if c.entryPoint != 0 {
+		return c.entryPoint
+	}
+	return c.loadAddress
+}
+
+// romCapacity returns the largest ROM that can be loaded at the load address.
+func (c *Chip8) romCapacity() int {
+	if int(c.loadAddress) > len(c.memory) {
+		return 0
+	}
+	return len(c.memory) - int(c.loadAddress)
+}
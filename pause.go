@@ -0,0 +1,22 @@
+package chip8
+
+// Pause freezes the machine. While paused, EmulateCycle executes nothing and
+// returns a Result with Paused set, and the timers do not count down.
+func (c *Chip8) Pause() {
+	c.paused = true
+}
+
+// Resume continues execution after Pause. The timers continue from where
+// they were paused, without catching up on the time spent paused.
+func (c *Chip8) Resume() {
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	c.lastTick = c.clock.Now()
+}
+
+// IsPaused returns true iff the machine has been paused with Pause.
+func (c *Chip8) IsPaused() bool {
+	return c.paused
+}
@@ -0,0 +1,98 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	clock := &ManualClock{}
+	// V0 = 0x20; delay_timer(V0); V1 += 1; goto 0x204
+	rom := BuildROM(0x6020, 0xF015, 0x7101, 0x1204)
+	cpu, err := New(bytes.NewReader(rom), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.Step(3); err != nil {
+		t.Fatal(err)
+	}
+
+	cpu.Pause()
+	if !cpu.IsPaused() {
+		t.Fatal("expected machine to be paused")
+	}
+	for i := 0; i < 5; i++ {
+		clock.Advance(timerPeriod)
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !r.Paused || r.OpcodeType != "" {
+			t.Errorf("expected a paused result, got %+v", r)
+		}
+	}
+	cpu.TickTimers()
+	expectPC(t, cpu, 0x206)
+	expectRegister(t, cpu, 1, 1)
+	if cpu.delayTimer != 0x20 {
+		t.Errorf("expected delay timer to stay at 0x20, got 0x%X", cpu.delayTimer)
+	}
+
+	// Time spent paused is not caught up on
+	cpu.Resume()
+	if cpu.IsPaused() {
+		t.Fatal("expected machine to be resumed")
+	}
+	clock.Advance(timerPeriod)
+	r, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Paused {
+		t.Errorf("expected cycle to execute")
+	}
+	expectPC(t, cpu, 0x204)
+	if cpu.delayTimer != 0x1F {
+		t.Errorf("expected delay timer 0x1F, got 0x%X", cpu.delayTimer)
+	}
+}
+
+func TestPausedResultState(t *testing.T) {
+	// V0 = 5; V1 += 1; goto 0x202
+	cpu, err := New(bytes.NewReader(BuildROM(0x6005, 0x7101, 0x1202)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.EmulateCycle(); err != nil {
+		t.Fatal(err)
+	}
+	cpu.Pause()
+	v, i := cpu.V, cpu.I
+
+	r, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Before.PC != 0x202 || r.After != r.Before || r.After.V != v {
+		t.Errorf("expected result to describe the current state, got %+v", r)
+	}
+	if cpu.V != v || cpu.I != i {
+		t.Errorf("expected registers to be unchanged")
+	}
+
+	// Batch execution stops rather than spinning while paused
+	results, err := cpu.RunCycles(10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no results while paused, got %d", len(results))
+	}
+	expectPC(t, cpu, 0x202)
+
+	cpu.Resume()
+	if _, err := cpu.RunCycles(4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 1, 2)
+}
@@ -0,0 +1,81 @@
+package chip8
+
+import "fmt"
+
+// Profile is a well known CHIP-8 interpreter, whose behavior can be
+// emulated by configuring the quirks it is known for.
+type Profile int
+
+const (
+	// ProfileCosmacVIP is the original interpreter for the COSMAC VIP.
+	ProfileCosmacVIP Profile = iota
+	// ProfileChip48 is CHIP-48 for the HP48 calculators.
+	ProfileChip48
+	// ProfileSuperChipLegacy is SUPER-CHIP 1.1 for the HP48 calculators.
+	ProfileSuperChipLegacy
+	// ProfileXOChip is XO-CHIP, as implemented by Octo.
+	ProfileXOChip
+)
+
+var profileNames = map[Profile]string{
+	ProfileCosmacVIP:       "vip",
+	ProfileChip48:          "chip48",
+	ProfileSuperChipLegacy: "schip",
+	ProfileXOChip:          "xochip",
+}
+
+// String returns the short name of the profile, as accepted by ParseProfile.
+func (p Profile) String() string {
+	if name, ok := profileNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Profile(%d)", int(p))
+}
+
+// ParseProfile returns the profile with the given short name:
+// "vip", "chip48", "schip" or "xochip".
+func ParseProfile(name string) (Profile, error) {
+	for p, n := range profileNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown profile %q", name)
+}
+
+// Quirks returns the quirks that match the behavior of the interpreter.
+// Only the COSMAC VIP waits for the display refresh before drawing.
+// CHIP-48 advancing I by one less than the COSMAC VIP after FX55 and FX65
+// is approximated by leaving I unchanged.
+func (p Profile) Quirks() Quirks {
+	switch p {
+	case ProfileCosmacVIP:
+		return Quirks{
+			KeyRelease:  true,
+			LoadStore:   true,
+			VFReset:     true,
+			DisplayWait: true,
+		}
+	case ProfileChip48:
+		return Quirks{
+			Shift: true,
+			Jump:  true,
+		}
+	case ProfileSuperChipLegacy:
+		return Quirks{
+			Shift: true,
+			Jump:  true,
+		}
+	case ProfileXOChip:
+		return Quirks{
+			LoadStore: true,
+			DrawWrap:  true,
+		}
+	}
+	return Quirks{}
+}
+
+// WithProfile configures the machine with the quirks of a profile.
+func WithProfile(p Profile) Option {
+	return WithQuirks(p.Quirks())
+}
@@ -0,0 +1,59 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProfileQuirks(t *testing.T) {
+	var tests = []struct {
+		profile  Profile
+		expected Quirks
+	}{
+		{
+			profile:  ProfileCosmacVIP,
+			expected: Quirks{KeyRelease: true, LoadStore: true, VFReset: true, DisplayWait: true},
+		},
+		{
+			profile:  ProfileChip48,
+			expected: Quirks{Shift: true, Jump: true},
+		},
+		{
+			profile:  ProfileSuperChipLegacy,
+			expected: Quirks{Shift: true, Jump: true},
+		},
+		{
+			profile:  ProfileXOChip,
+			expected: Quirks{LoadStore: true, DrawWrap: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.profile.String(), func(t *testing.T) {
+			if got := test.profile.Quirks(); got != test.expected {
+				t.Errorf("expected quirks %+v, got %+v", test.expected, got)
+			}
+
+			cpu, err := New(bytes.NewReader(nil), WithProfile(test.profile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cpu.quirks != test.expected {
+				t.Errorf("expected machine quirks %+v, got %+v", test.expected, cpu.quirks)
+			}
+
+			parsed, err := ParseProfile(test.profile.String())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if parsed != test.profile {
+				t.Errorf("expected to parse %v, got %v", test.profile, parsed)
+			}
+		})
+	}
+}
+
+func TestParseProfileUnknown(t *testing.T) {
+	if _, err := ParseProfile("amiga"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
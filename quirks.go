@@ -0,0 +1,46 @@
+package chip8
+
+// Quirks selects between behaviors that differ across CHIP-8 interpreters.
+// The zero value preserves the default behavior of this emulator.
+type Quirks struct {
+	// KeyRelease makes FX0A complete only once the pressed key has been
+	// released, as on the original COSMAC VIP.
+	KeyRelease bool
+
+	// Shift makes 8XY6 and 8XYE shift VX in place and ignore VY, as on
+	// CHIP-48 and SUPER-CHIP. By default VY is shifted into VX.
+	Shift bool
+
+	// DrawWrap makes sprite pixels drawn beyond the edges of the display
+	// wrap around to the opposite edge. By default they are clipped.
+	DrawWrap bool
+
+	// LoadStore makes FX55 and FX65 advance I past the registers stored or
+	// loaded, as on the COSMAC VIP. By default I is unchanged.
+	LoadStore bool
+
+	// Jump makes BNNN jump to NNN plus VX, where X is the highest digit of
+	// NNN, as on CHIP-48 and SUPER-CHIP. By default V0 is used.
+	Jump bool
+
+	// IndexOverflow makes FX1E set VF to 1 when I is advanced past 0xFFF,
+	// and to 0 otherwise, as on the Amiga interpreter. By default VF is
+	// unchanged. I wraps to 12 bits either way.
+	IndexOverflow bool
+
+	// VFReset makes 8XY1, 8XY2 and 8XY3 reset VF to 0, as on the COSMAC VIP.
+	// By default VF is unchanged.
+	VFReset bool
+
+	// DisplayWait makes DXYN wait for the next 60Hz timer tick before
+	// drawing, limiting programs to one sprite per frame as on the COSMAC
+	// VIP. By default sprites are drawn immediately.
+	DisplayWait bool
+}
+
+// WithQuirks configures the machine to use a particular set of quirks.
+func WithQuirks(q Quirks) Option {
+	return func(c *Chip8) {
+		c.quirks = q
+	}
+}
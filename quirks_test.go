@@ -0,0 +1,151 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestQuirksOptions runs a ROM affected by each quirk with and without it
+// configured by WithQuirks, to confirm the option reaches the handlers and
+// that the default behavior is unchanged.
+func TestQuirksOptions(t *testing.T) {
+	var tests = []struct {
+		name    string
+		quirks  Quirks
+		rom     []byte
+		cycles  int
+		inspect func(cpu *Chip8) uint16
+		// Values returned by inspect without and with the quirk
+		expectedDefault uint16
+		expectedQuirk   uint16
+	}{
+		{
+			name:   "shift",
+			quirks: Quirks{Shift: true},
+			// V0 = 0x81; V1 = 0x42; V0 >>= 1 or V0 = V1 >> 1
+			rom:             BuildROM(0x6081, 0x6142, 0x8016),
+			cycles:          3,
+			inspect:         func(cpu *Chip8) uint16 { return uint16(cpu.V[0]) },
+			expectedDefault: 0x21,
+			expectedQuirk:   0x40,
+		},
+		{
+			name:   "load/store",
+			quirks: Quirks{LoadStore: true},
+			// I = 0x300; reg_dump(V0, &I)
+			rom:             BuildROM(0xA300, 0xF055),
+			cycles:          2,
+			inspect:         func(cpu *Chip8) uint16 { return cpu.I },
+			expectedDefault: 0x300,
+			expectedQuirk:   0x301,
+		},
+		{
+			name:   "jump",
+			quirks: Quirks{Jump: true},
+			// V1 = 5; PC = V0 + 0x100 or PC = V1 + 0x100
+			rom:             BuildROM(0x6105, 0xB100),
+			cycles:          2,
+			inspect:         func(cpu *Chip8) uint16 { return cpu.pc },
+			expectedDefault: 0x100,
+			expectedQuirk:   0x105,
+		},
+		{
+			name:   "draw wrap",
+			quirks: Quirks{DrawWrap: true},
+			// V0 = 62; V1 = 0; V2 = 0; I = sprite_addr[V2]; draw(V0, V1, 5)
+			rom:             BuildROM(0x603E, 0x6100, 0x6200, 0xF229, 0xD015),
+			cycles:          5,
+			inspect:         func(cpu *Chip8) uint16 { return uint16(cpu.gfx[0]) },
+			expectedDefault: 0,
+			expectedQuirk:   1,
+		},
+		{
+			name:   "index overflow",
+			quirks: Quirks{IndexOverflow: true},
+			// I = 0xFFF; V0 = 0x10; I += V0
+			rom:             BuildROM(0xAFFF, 0x6010, 0xF01E),
+			cycles:          3,
+			inspect:         func(cpu *Chip8) uint16 { return uint16(cpu.V[0xF]) },
+			expectedDefault: 0,
+			expectedQuirk:   1,
+		},
+		{
+			name:   "VF reset",
+			quirks: Quirks{VFReset: true},
+			// VF = 1; V0 |= V1
+			rom:             BuildROM(0x6F01, 0x8011),
+			cycles:          2,
+			inspect:         func(cpu *Chip8) uint16 { return uint16(cpu.V[0xF]) },
+			expectedDefault: 1,
+			expectedQuirk:   0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			run := func(opts ...Option) uint16 {
+				cpu, err := New(bytes.NewReader(test.rom), opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 0; i < test.cycles; i++ {
+					if _, err := cpu.EmulateCycle(); err != nil {
+						t.Fatalf("unexpected error on cycle %d: %v", i, err)
+					}
+				}
+				return test.inspect(cpu)
+			}
+
+			if got := run(); got != test.expectedDefault {
+				t.Errorf("expected 0x%X without options, got 0x%X", test.expectedDefault, got)
+			}
+			if got := run(WithQuirks(Quirks{})); got != test.expectedDefault {
+				t.Errorf("expected 0x%X with no quirks, got 0x%X", test.expectedDefault, got)
+			}
+			if got := run(WithQuirks(test.quirks)); got != test.expectedQuirk {
+				t.Errorf("expected 0x%X with quirk, got 0x%X", test.expectedQuirk, got)
+			}
+		})
+	}
+}
+
+func TestDisplayWait(t *testing.T) {
+	// I = 0x50; draw(V0,V0,5); draw(V0,V0,5); goto 0x206
+	rom := BuildROM(0xA050, 0xD005, 0xD005, 0x1206)
+
+	// cycles runs until the program reaches the final jump, returning the
+	// number of cycles and frames that took
+	cycles := func(quirks Quirks) (int, int) {
+		t.Helper()
+		clock := &ManualClock{}
+		cpu, err := New(bytes.NewReader(rom), WithQuirks(quirks), WithClock(clock), WithFullResults())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n, frames int
+		for cpu.pc != 0x206 {
+			r, err := cpu.EmulateCycle()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			n++
+			if r.OpcodeType == "0xDXYN (waiting)" {
+				if r.Pseudo != "draw(V0,V0,5)" {
+					t.Errorf("unexpected pseudo: %q", r.Pseudo)
+				}
+				clock.Advance(timerPeriod)
+				frames++
+			}
+			if n > 10 {
+				t.Fatalf("expected draws to complete, pc at 0x%X", cpu.pc)
+			}
+		}
+		return n, frames
+	}
+
+	if n, frames := cycles(Quirks{}); n != 3 || frames != 0 {
+		t.Errorf("expected 3 cycles without waiting, got %d cycles over %d frames", n, frames)
+	}
+	if n, frames := cycles(Quirks{DisplayWait: true}); n != 5 || frames != 2 {
+		t.Errorf("expected 5 cycles over 2 frames, got %d cycles over %d frames", n, frames)
+	}
+}
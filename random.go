@@ -0,0 +1,24 @@
+package chip8
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithRandSeed seeds the source of random numbers used by CXNN, so that
+// programs using randomness can be run reproducibly.
+// By default, each machine is seeded from the time it first needs a random
+// number.
+func WithRandSeed(seed int64) Option {
+	return func(c *Chip8) {
+		c.random = rand.New(rand.NewSource(seed))
+	}
+}
+
+// randomByte returns a random byte, uniformly distributed over 0-255.
+func (c *Chip8) randomByte() byte {
+	if c.random == nil {
+		c.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return byte(c.random.Intn(256))
+}
@@ -0,0 +1,50 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRandSeed(t *testing.T) {
+	// V0 = rand() & 0xFF; V1 = rand() & 0x0F; V2 = rand() & 0xF0
+	rom := BuildROM(0xC0FF, 0xC10F, 0xC2F0)
+	run := func() [16]byte {
+		t.Helper()
+		cpu, err := New(bytes.NewReader(rom), WithRandSeed(42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if cpu.V[1]&0xF0 != 0 || cpu.V[2]&0x0F != 0 {
+			t.Errorf("expected values to be masked, got V1=0x%02X V2=0x%02X", cpu.V[1], cpu.V[2])
+		}
+		return cpu.V
+	}
+	if first, second := run(), run(); first != second {
+		t.Errorf("expected the same values from the same seed, got %v and %v", first, second)
+	}
+}
+
+func TestRandomByteFullRange(t *testing.T) {
+	cpu, err := New(bytes.NewReader(BuildROM(0xC0FF)), WithRandSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen [256]bool
+	for i := 0; i < 10000; i++ {
+		cpu.pc = 0x200
+		if _, err := cpu.opcode0xC000(0xC0FF); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		seen[cpu.V[0]] = true
+	}
+	for v, ok := range seen {
+		if !ok {
+			t.Errorf("value 0x%02X was never generated", v)
+		}
+	}
+}
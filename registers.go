@@ -0,0 +1,61 @@
+package chip8
+
+import "fmt"
+
+// Registers is a copy of the CPU registers, timers and stack of a machine.
+type Registers struct {
+	V  [16]byte
+	I  uint16
+	PC uint16
+	// Stack pointer, the number of entries in use in Stack. The most recent
+	// entry is Stack[SP-1].
+	SP    uint16
+	Stack [16]uint16
+
+	DelayTimer byte
+	SoundTimer byte
+}
+
+// Registers returns a copy of the current register values, for display in a
+// debugger.
+func (c *Chip8) Registers() Registers {
+	return Registers{
+		V:          c.V,
+		I:          c.I,
+		PC:         c.pc,
+		SP:         c.sp,
+		Stack:      c.stack,
+		DelayTimer: c.delayTimer,
+		SoundTimer: c.soundTimer,
+	}
+}
+
+// IndexRegister returns the value of the index register, I.
+func (c *Chip8) IndexRegister() uint16 {
+	return c.I
+}
+
+// ProgramCounter returns the address of the next opcode to be executed.
+func (c *Chip8) ProgramCounter() uint16 {
+	return c.pc
+}
+
+// StackPointer returns the number of entries in use in the stack.
+func (c *Chip8) StackPointer() uint16 {
+	return c.sp
+}
+
+// Stack returns a copy of the stack of subroutine return addresses.
+func (c *Chip8) Stack() [16]uint16 {
+	return c.stack
+}
+
+// SetRegister sets the value of register V0-VF, for use in a debugger.
+// An error is returned if index does not identify a register.
+func (c *Chip8) SetRegister(index int, value byte) error {
+	if index < 0 || index >= len(c.V) {
+		return fmt.Errorf("invalid register %d", index)
+	}
+	c.V[index] = value
+	return nil
+}
@@ -0,0 +1,79 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegisters(t *testing.T) {
+	// V0 = 0x20; delay_timer(V0); I = 0x300; call 0x208; V1 = 7
+	rom := BuildROM(0x6020, 0xF015, 0xA300, 0x2208, 0x6107)
+	cpu, err := New(bytes.NewReader(rom), WithClock(&ManualClock{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.Step(5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Registers{
+		V:          [16]byte{0: 0x20, 1: 7},
+		I:          0x300,
+		PC:         0x20A,
+		SP:         1,
+		Stack:      [16]uint16{0x206},
+		DelayTimer: 0x20,
+	}
+	if r := cpu.Registers(); r != expected {
+		t.Errorf("expected %+v, got %+v", expected, r)
+	}
+
+	// The registers are a copy
+	r := cpu.Registers()
+	r.V[0] = 0
+	expectRegister(t, cpu, 0, 0x20)
+}
+
+func TestRegisterAccessors(t *testing.T) {
+	// I = 0x300; call 0x206; ...; V0 = 1
+	cpu, err := New(bytes.NewReader(BuildROM(0xA300, 0x2206, 0x0000, 0x6001)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.Step(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i := cpu.IndexRegister(); i != 0x300 {
+		t.Errorf("expected I = 0x300, got 0x%X", i)
+	}
+	if pc := cpu.ProgramCounter(); pc != 0x208 {
+		t.Errorf("expected PC = 0x208, got 0x%X", pc)
+	}
+	if sp := cpu.StackPointer(); sp != 1 {
+		t.Errorf("expected SP = 1, got %d", sp)
+	}
+
+	// The stack is a copy
+	stack := cpu.Stack()
+	if stack != [16]uint16{0x202} {
+		t.Errorf("expected return address 0x202, got %v", stack)
+	}
+	stack[0] = 0
+	if cpu.stack[0] != 0x202 {
+		t.Errorf("expected stack to be unchanged by modifying the copy")
+	}
+}
+
+func TestSetRegister(t *testing.T) {
+	cpu := initCPU()
+	if err := cpu.SetRegister(0xF, 0x42); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectRegister(t, cpu, 0xF, 0x42)
+
+	for _, index := range []int{-1, 16} {
+		if err := cpu.SetRegister(index, 1); err == nil {
+			t.Errorf("expected an error for register %d", index)
+		}
+	}
+}
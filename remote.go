@@ -0,0 +1,66 @@
+package chip8
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// defaultFetchTimeout bounds the time taken to download a ROM when no
+// http.Client is provided with WithHTTPClient.
+const defaultFetchTimeout = 30 * time.Second
+
+// NewFromURL creates a new CHIP-8 machine as with New, loading the ROM
+// from the body of an HTTP GET request to url.
+//
+// The request is made with the client provided via WithHTTPClient, or a
+// default client with a 30 second timeout. If WithHTTPClient is given a nil
+// client, http.DefaultClient is used.
+// An error is returned if the response is not 200 OK, or the ROM is too
+// large to fit into memory.
+func NewFromURL(url string, opts ...Option) (*Chip8, error) {
+	c := newChip8(opts)
+
+	rom, err := c.fetchROM(url)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.loadROM(bytes.NewReader(rom))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// fetchROM downloads a ROM, reading no more than one byte past the
+// maximum ROM size.
+func (c *Chip8) fetchROM(url string) ([]byte, error) {
+	client := c.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", url, resp.Status)
+	}
+
+	maxROMSize := c.romCapacity()
+	rom, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(maxROMSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(rom) > maxROMSize {
+		return nil, fmt.Errorf("fetching %s: ROM exceeds %d bytes", url, maxROMSize)
+	}
+	return rom, nil
+}
@@ -0,0 +1,59 @@
+package chip8
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewFromURL(t *testing.T) {
+	rom := []byte{0x60, 0x2A, 0x12, 0x02}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rom.ch8":
+			w.Write(rom)
+		case "/large.ch8":
+			w.Write(make([]byte, 4096-defaultLoadAddress+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("loads rom", func(t *testing.T) {
+		cpu, err := NewFromURL(server.URL+"/rom.ch8", WithHTTPClient(server.Client()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, b := range rom {
+			if cpu.memory[0x200+i] != b {
+				t.Errorf("memory[0x%X] should be 0x%X, got 0x%X", 0x200+i, b, cpu.memory[0x200+i])
+			}
+		}
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectRegister(t, cpu, 0, 0x2A)
+	})
+
+	t.Run("nil client", func(t *testing.T) {
+		if _, err := NewFromURL(server.URL+"/rom.ch8", WithHTTPClient(nil)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		_, err := NewFromURL(server.URL+"/large.ch8", WithHTTPClient(server.Client()))
+		if err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Errorf("expected size error, got %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := NewFromURL(server.URL+"/missing.ch8", WithHTTPClient(server.Client()))
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("expected status error, got %v", err)
+		}
+	})
+}
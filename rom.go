@@ -0,0 +1,11 @@
+package chip8
+
+// BuildROM packs opcodes into the big-endian byte order used by ROMs,
+// so a program can be written directly as a list of opcodes.
+func BuildROM(instructions ...uint16) []byte {
+	rom := make([]byte, 0, len(instructions)*2)
+	for _, opcode := range instructions {
+		rom = append(rom, byte(opcode>>8), byte(opcode))
+	}
+	return rom
+}
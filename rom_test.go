@@ -0,0 +1,124 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBuildROM(t *testing.T) {
+	rom := BuildROM(0x6A05, 0x7A03)
+	expected := []byte{0x6A, 0x05, 0x7A, 0x03}
+	if !bytes.Equal(rom, expected) {
+		t.Fatalf("expected % X, got % X", expected, rom)
+	}
+
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expectRegister(t, cpu, 0xA, 0x08)
+	expectPC(t, cpu, 0x204)
+}
+
+func TestLoad(t *testing.T) {
+	cpu := initCPU()
+	rom := BuildROM(0x6A05, 0x7A03)
+	if err := cpu.Load(rom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(cpu.memory[0x200:0x204], rom) {
+		t.Errorf("expected ROM at 0x200, got % X", cpu.memory[0x200:0x204])
+	}
+	expectPC(t, cpu, 0x200)
+
+	err := cpu.Load(make([]byte, 4096-0x200+1))
+	if !errors.Is(err, ErrROMTooLarge) {
+		t.Fatalf("expected rom too large error, got %v", err)
+	}
+	if err.Error() != "rom too large: 3585 bytes, max 3584" {
+		t.Errorf("unexpected error message: %q", err)
+	}
+	if !bytes.Equal(cpu.memory[0x200:0x204], rom) {
+		t.Errorf("expected ROM to be unchanged")
+	}
+}
+
+func TestNewROMTooLarge(t *testing.T) {
+	_, err := New(bytes.NewReader(make([]byte, 4000)))
+	if !errors.Is(err, ErrROMTooLarge) {
+		t.Fatalf("expected rom too large error, got %v", err)
+	}
+	if err.Error() != "rom too large: 4000 bytes, max 3584" {
+		t.Errorf("unexpected error message: %q", err)
+	}
+}
+
+func TestNewROMReaders(t *testing.T) {
+	full := make([]byte, 4096-0x200)
+	for i := range full {
+		full[i] = byte(i)
+	}
+	var tests = []struct {
+		name   string
+		reader io.Reader
+		rom    []byte
+	}{
+		{
+			name:   "empty",
+			reader: bytes.NewReader(nil),
+		},
+		{
+			name:   "small chunks",
+			reader: iotest.HalfReader(bytes.NewReader(full)),
+			rom:    full,
+		},
+		{
+			name:   "one byte at a time",
+			reader: iotest.OneByteReader(bytes.NewReader(full[:100])),
+			rom:    full[:100],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, err := New(test.reader)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cpu.romSize != len(test.rom) {
+				t.Errorf("expected ROM of %d bytes, got %d", len(test.rom), cpu.romSize)
+			}
+			loaded := cpu.memory[0x200 : 0x200+len(test.rom)]
+			if !bytes.Equal(loaded, test.rom) {
+				t.Errorf("expected the whole ROM to be loaded")
+			}
+			if !isZero(cpu.memory[0x200+len(test.rom):]) {
+				t.Errorf("expected memory after the ROM to be empty")
+			}
+		})
+	}
+}
+
+func TestLoadROMTooLargeWritesNothing(t *testing.T) {
+	cpu := initCPU()
+	rom := make([]byte, 4096)
+	for i := range rom {
+		rom[i] = 0xFF
+	}
+	err := cpu.loadROM(bytes.NewReader(rom))
+	if !errors.Is(err, ErrROMTooLarge) {
+		t.Fatalf("expected rom too large error, got %v", err)
+	}
+	for addr := 0x200; addr < len(cpu.memory); addr++ {
+		if cpu.memory[addr] != 0 {
+			t.Fatalf("expected memory at 0x%03X to be unchanged", addr)
+		}
+	}
+}
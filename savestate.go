@@ -0,0 +1,203 @@
+package chip8
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Save states begin with saveStateMagic, followed by a version number.
+// Version 2 added the quirks, in the layout of saveStateQuirksV2. Quirks
+// added since then are not saved until a new version with its own layout
+// is introduced.
+const (
+	saveStateMagic   = "CH8S"
+	saveStateVersion = 2
+)
+
+// saveStateHeader identifies a save state and its format.
+type saveStateHeader struct {
+	Magic   [4]byte
+	Version uint16
+}
+
+// saveStateFixed is the fixed size portion of a save state, which is
+// followed by the contents of both bit-planes and then the Quirks.
+type saveStateFixed struct {
+	Memory [4096]byte
+	V      [16]byte
+	RPL    [8]byte
+	I      uint16
+	PC     uint16
+	SP     uint16
+	Stack  [16]uint16
+
+	Width, Height uint16
+	PlaneMask     byte
+	DrawFlag      bool
+
+	DelayTimer byte
+	SoundTimer byte
+
+	Key            [16]byte
+	WaitingForKey  bool
+	KeyRegister    uint16
+	WaitKey        byte
+	WaitKeyPressed bool
+
+	Halted bool
+}
+
+// saveStateQuirksV2 is the layout of the quirks in a version 2 save state.
+// It is independent of Quirks, so that adding a quirk does not change the
+// format.
+type saveStateQuirksV2 struct {
+	KeyRelease    bool
+	Shift         bool
+	DrawWrap      bool
+	LoadStore     bool
+	Jump          bool
+	IndexOverflow bool
+	VFReset       bool
+	DisplayWait   bool
+}
+
+// newSaveStateQuirksV2 converts quirks to their saved layout.
+func newSaveStateQuirksV2(q Quirks) saveStateQuirksV2 {
+	return saveStateQuirksV2{
+		KeyRelease:    q.KeyRelease,
+		Shift:         q.Shift,
+		DrawWrap:      q.DrawWrap,
+		LoadStore:     q.LoadStore,
+		Jump:          q.Jump,
+		IndexOverflow: q.IndexOverflow,
+		VFReset:       q.VFReset,
+		DisplayWait:   q.DisplayWait,
+	}
+}
+
+// quirks converts saved quirks back to Quirks.
+func (s saveStateQuirksV2) quirks() Quirks {
+	return Quirks{
+		KeyRelease:    s.KeyRelease,
+		Shift:         s.Shift,
+		DrawWrap:      s.DrawWrap,
+		LoadStore:     s.LoadStore,
+		Jump:          s.Jump,
+		IndexOverflow: s.IndexOverflow,
+		VFReset:       s.VFReset,
+		DisplayWait:   s.DisplayWait,
+	}
+}
+
+// WriteSaveState writes the state of the machine and its active quirks to w
+// in a compact binary format, that can be restored with ReadSaveState.
+func (c *Chip8) WriteSaveState(w io.Writer) error {
+	s := c.Snapshot()
+	header := saveStateHeader{Version: saveStateVersion}
+	copy(header.Magic[:], saveStateMagic)
+	fixed := saveStateFixed{
+		V:              s.V,
+		RPL:            s.RPL,
+		I:              s.I,
+		PC:             s.PC,
+		SP:             s.SP,
+		Stack:          s.Stack,
+		Width:          uint16(s.Width),
+		Height:         uint16(s.Height),
+		PlaneMask:      s.PlaneMask,
+		DrawFlag:       s.DrawFlag,
+		DelayTimer:     s.DelayTimer,
+		SoundTimer:     s.SoundTimer,
+		Key:            s.Key,
+		WaitingForKey:  s.WaitingForKey,
+		KeyRegister:    s.KeyRegister,
+		WaitKey:        s.WaitKey,
+		WaitKeyPressed: s.WaitKeyPressed,
+		Halted:         s.Halted,
+	}
+	copy(fixed.Memory[:], s.Memory)
+
+	quirks := newSaveStateQuirksV2(c.quirks)
+	for _, data := range []interface{}{header, &fixed, s.Gfx, s.Gfx2, &quirks} {
+		if err := binary.Write(w, binary.BigEndian, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSaveState restores the state of the machine from a save state written
+// by WriteSaveState. The state is only applied if it is read successfully.
+// Save states from version 1, which predate saving the quirks, leave the
+// quirks unchanged. Other configuration, such as hooks and the clock, is
+// never affected.
+// An error wrapping ErrSaveStateFormat is returned if r does not contain a
+// save state, or ErrStateVersion if it is from an unsupported version.
+func (c *Chip8) ReadSaveState(r io.Reader) error {
+	var header saveStateHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return fmt.Errorf("%w: reading header: %v", ErrSaveStateFormat, err)
+	}
+	if string(header.Magic[:]) != saveStateMagic {
+		return fmt.Errorf("%w: unexpected magic number %q", ErrSaveStateFormat, header.Magic[:])
+	}
+	if header.Version < 1 || header.Version > saveStateVersion {
+		return fmt.Errorf("%w: save state version %d", ErrStateVersion, header.Version)
+	}
+
+	var fixed saveStateFixed
+	if err := binary.Read(r, binary.BigEndian, &fixed); err != nil {
+		return fmt.Errorf("%w: %v", ErrSaveStateFormat, err)
+	}
+	// Check the resolution before reading the display, to size it safely
+	width, height := int(fixed.Width), int(fixed.Height)
+	if !(width == lowResWidth && height == lowResHeight) && !(width == highResWidth && height == highResHeight) {
+		return fmt.Errorf("%w: unsupported resolution %dx%d", ErrSaveStateFormat, width, height)
+	}
+	gfx := make([]byte, width*height)
+	gfx2 := make([]byte, width*height)
+	for _, plane := range [][]byte{gfx, gfx2} {
+		if _, err := io.ReadFull(r, plane); err != nil {
+			return fmt.Errorf("%w: reading display: %v", ErrSaveStateFormat, err)
+		}
+	}
+	quirks := c.quirks
+	if header.Version >= 2 {
+		var saved saveStateQuirksV2
+		if err := binary.Read(r, binary.BigEndian, &saved); err != nil {
+			return fmt.Errorf("%w: reading quirks: %v", ErrSaveStateFormat, err)
+		}
+		quirks = saved.quirks()
+	}
+
+	err := c.Restore(State{
+		Version:        stateVersion,
+		Memory:         fixed.Memory[:],
+		V:              fixed.V,
+		RPL:            fixed.RPL,
+		I:              fixed.I,
+		PC:             fixed.PC,
+		SP:             fixed.SP,
+		Stack:          fixed.Stack,
+		Gfx:            gfx,
+		Gfx2:           gfx2,
+		Width:          width,
+		Height:         height,
+		PlaneMask:      fixed.PlaneMask,
+		DrawFlag:       fixed.DrawFlag,
+		DelayTimer:     fixed.DelayTimer,
+		SoundTimer:     fixed.SoundTimer,
+		Key:            fixed.Key,
+		WaitingForKey:  fixed.WaitingForKey,
+		KeyRegister:    fixed.KeyRegister,
+		WaitKey:        fixed.WaitKey,
+		WaitKeyPressed: fixed.WaitKeyPressed,
+		Halted:         fixed.Halted,
+	})
+	if err != nil {
+		return err
+	}
+	c.quirks = quirks
+	return nil
+}
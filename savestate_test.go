@@ -0,0 +1,170 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSaveStateRoundTrip(t *testing.T) {
+	// high_res(); V0 = 5; V1 = 0xA; I = 0x50; draw(V0,V1,5); V0 += 1
+	rom := BuildROM(0x00FF, 0x6005, 0x610A, 0xA050, 0xD015, 0x7001)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cpu.delayTimer = 0x20
+	cpu.key[3] = 1
+	cpu.rpl[0] = 0x99
+
+	var saved bytes.Buffer
+	if err := cpu.WriteSaveState(&saved); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(saved.Bytes(), []byte(saveStateMagic)) {
+		t.Errorf("expected save state to begin with magic number")
+	}
+
+	restored := initCPU()
+	if err := restored.ReadSaveState(&saved); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected, got := cpu.Snapshot(), restored.Snapshot(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected state %+v, got %+v", expected, got)
+	}
+	if saved.Len() != 0 {
+		t.Errorf("expected the whole save state to be read, %d bytes remain", saved.Len())
+	}
+}
+
+func TestSaveStateQuirks(t *testing.T) {
+	quirks := Quirks{Shift: true, LoadStore: true, DisplayWait: true}
+	cpu, err := New(bytes.NewReader(nil), WithQuirks(quirks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved bytes.Buffer
+	if err := cpu.WriteSaveState(&saved); err != nil {
+		t.Fatal(err)
+	}
+	data := saved.Bytes()
+
+	restored := initCPU()
+	if err := restored.ReadSaveState(bytes.NewReader(data)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.quirks != quirks {
+		t.Errorf("expected quirks %+v, got %+v", quirks, restored.quirks)
+	}
+
+	// Version 1 save states have no quirks, which are left unchanged
+	v1 := append([]byte(nil), data[:len(data)-binary.Size(saveStateQuirksV2{})]...)
+	v1[5] = 1
+	restored = initCPU()
+	restored.quirks = Quirks{Jump: true}
+	if err := restored.ReadSaveState(bytes.NewReader(v1)); err != nil {
+		t.Fatalf("unexpected error reading version 1: %v", err)
+	}
+	if restored.quirks != (Quirks{Jump: true}) {
+		t.Errorf("expected quirks to be unchanged, got %+v", restored.quirks)
+	}
+}
+
+func TestSaveStateAllQuirks(t *testing.T) {
+	// A quirk added since version 2 fails this test, as saving it needs a new version
+	var quirks Quirks
+	v := reflect.ValueOf(&quirks).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		v.Field(i).SetBool(true)
+	}
+	if saved := newSaveStateQuirksV2(quirks).quirks(); saved != quirks {
+		t.Errorf("expected quirks %+v to be saved, got %+v", quirks, saved)
+	}
+	if size := binary.Size(saveStateQuirksV2{}); size != 8 {
+		t.Errorf("expected version 2 quirks to be 8 bytes, got %d", size)
+	}
+}
+
+func TestSaveStateReplay(t *testing.T) {
+	// V0 += 1; I = 0x50; draw(V0,V0,5); V1 += V0; call 0x20C; goto 0x200; return
+	rom := BuildROM(0x7001, 0xA050, 0xD005, 0x8104, 0x220C, 0x1200, 0x00EE)
+	cpu, err := New(bytes.NewReader(rom), WithClock(&ManualClock{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.Step(10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var saved bytes.Buffer
+	if err := cpu.WriteSaveState(&saved); err != nil {
+		t.Fatal(err)
+	}
+	first, err := cpu.Step(20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := cpu.ReadSaveState(&saved); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := cpu.Step(20)
+	if err != nil {
+		t.Fatalf("unexpected error after restore: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same results after restoring, got %+v and %+v", first, second)
+	}
+}
+
+func TestReadSaveStateInvalid(t *testing.T) {
+	var valid bytes.Buffer
+	if err := initCPU().WriteSaveState(&valid); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{
+			name:     "bad magic",
+			data:     append([]byte("PNG!"), valid.Bytes()[4:]...),
+			expected: ErrSaveStateFormat,
+		},
+		{
+			name:     "version",
+			data:     append([]byte("CH8S\x00\x63"), valid.Bytes()[6:]...),
+			expected: ErrStateVersion,
+		},
+		{
+			name:     "truncated",
+			data:     valid.Bytes()[:valid.Len()-1],
+			expected: ErrSaveStateFormat,
+		},
+		{
+			name:     "empty",
+			expected: ErrSaveStateFormat,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.V[0] = 0x12
+			err := cpu.ReadSaveState(bytes.NewReader(test.data))
+			if !errors.Is(err, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, err)
+			}
+			// The machine is unchanged
+			expectRegister(t, cpu, 0, 0x12)
+		})
+	}
+}
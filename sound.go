@@ -0,0 +1,65 @@
+package chip8
+
+import "time"
+
+// Beeps are generated by GenerateTone as a square wave of toneFrequency Hz,
+// at a quarter of full volume.
+const (
+	toneFrequency = 440
+	toneAmplitude = 0x2000
+)
+
+// beepBufferSize is the number of beeps that can be waiting to be read from
+// the Beep channel before further beeps are dropped.
+const beepBufferSize = 16
+
+// BeepEvent describes a beep to be played.
+type BeepEvent struct {
+	// How long the beep lasts if the sound timer is not set again,
+	// calculated from the value the sound timer was set to
+	Duration time.Duration
+}
+
+// startSound sets the sound timer, outputting a beep on the Beep channel
+// for the time it will count down for.
+// The beep is dropped if the channel's buffer is full.
+func (c *Chip8) startSound(value byte) {
+	c.soundTimer = value
+	if value == 0 {
+		return
+	}
+	c.metrics.Beeps++
+	select {
+	case c.beepOut <- BeepEvent{Duration: time.Duration(value) * timerPeriod}:
+	default:
+	}
+}
+
+// GenerateTone returns signed 16-bit mono PCM samples of a beep lasting d,
+// at sampleRate samples per second. The beep is a 440Hz square wave.
+// Samples for a BeepEvent can be generated from its Duration.
+func GenerateTone(sampleRate int, d time.Duration) []int16 {
+	samples := make([]int16, SampleCount(sampleRate, d))
+	for i := range samples {
+		// Alternate every half cycle of the tone
+		if int64(i)*2*toneFrequency/int64(sampleRate)%2 == 0 {
+			samples[i] = toneAmplitude
+		} else {
+			samples[i] = -toneAmplitude
+		}
+	}
+	return samples
+}
+
+// SampleCount returns the number of samples needed to play sound for d at
+// sampleRate samples per second.
+func SampleCount(sampleRate int, d time.Duration) int {
+	return int(int64(sampleRate) * int64(d) / int64(time.Second))
+}
+
+// SoundSamples returns the number of samples needed at sampleRate to play
+// sound until the sound timer reaches zero.
+func (c *Chip8) SoundSamples(sampleRate int) int {
+	// The timer counts down at 60Hz
+	return sampleRate * int(c.soundTimer) / 60
+}
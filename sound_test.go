@@ -0,0 +1,50 @@
+package chip8
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateTone(t *testing.T) {
+	var tests = []struct {
+		sampleRate int
+		duration   time.Duration
+		expected   int
+	}{
+		{sampleRate: 44100, duration: time.Second, expected: 44100},
+		{sampleRate: 44100, duration: 250 * time.Millisecond, expected: 11025},
+		{sampleRate: 8000, duration: 2 * time.Second, expected: 16000},
+		{sampleRate: 44100, duration: 0, expected: 0},
+	}
+	for _, test := range tests {
+		samples := GenerateTone(test.sampleRate, test.duration)
+		if len(samples) != test.expected {
+			t.Errorf("%v at %dHz: expected %d samples, got %d", test.duration, test.sampleRate, test.expected, len(samples))
+		}
+	}
+
+	// At 8800Hz, each half cycle of a 440Hz tone is 10 samples
+	samples := GenerateTone(8800, time.Second)
+	for i, s := range samples[:40] {
+		expected := int16(toneAmplitude)
+		if (i/10)%2 == 1 {
+			expected = -toneAmplitude
+		}
+		if s != expected {
+			t.Fatalf("sample %d: expected %d, got %d", i, expected, s)
+		}
+	}
+}
+
+func TestSoundSamples(t *testing.T) {
+	cpu := initCPU()
+	cpu.soundTimer = 30
+	// Half a second of sound remains
+	if got := cpu.SoundSamples(44100); got != 22050 {
+		t.Errorf("expected 22050 samples, got %d", got)
+	}
+	cpu.updateTimers()
+	if got := cpu.SoundSamples(6000); got != 2900 {
+		t.Errorf("expected 2900 samples, got %d", got)
+	}
+}
@@ -0,0 +1,65 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestStackOverflow(t *testing.T) {
+	// V0 += 1; call 0x200
+	rom := BuildROM(0x7001, 0x2200)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	for calls = 0; calls < 17; calls++ {
+		if _, err = cpu.Step(2); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrStackOverflow) {
+		t.Fatalf("expected stack overflow, got %v", err)
+	}
+	// The stack holds 16 return addresses, so only the 17th call fails
+	if calls != 16 {
+		t.Errorf("expected 16 calls to succeed, got %d", calls)
+	}
+	var emuErr *Error
+	if !errors.As(err, &emuErr) || emuErr.PC != 0x202 || emuErr.Opcode != 0x2200 {
+		t.Errorf("expected error for opcode 0x2200 at 0x202, got %v", err)
+	}
+
+	// The machine is left as it was before the failed call
+	if depth := cpu.StackDepth(); depth != calls {
+		t.Errorf("expected stack depth %d, got %d", calls, depth)
+	}
+	expectPC(t, cpu, 0x202)
+	expectStack(t, cpu, 0x202)
+	expectRegister(t, cpu, 0, byte(calls+1))
+}
+
+func TestStackUnderflow(t *testing.T) {
+	// V0 = 1; return
+	rom := BuildROM(0x6001, 0x00EE)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cpu.Step(2)
+	if !errors.Is(err, ErrStackUnderflow) {
+		t.Fatalf("expected stack underflow, got %v", err)
+	}
+	var emuErr *Error
+	if !errors.As(err, &emuErr) || emuErr.PC != 0x202 || emuErr.Opcode != 0x00EE {
+		t.Errorf("expected error for opcode 0x00EE at 0x202, got %v", err)
+	}
+	expectPC(t, cpu, 0x202)
+	if depth := cpu.StackDepth(); depth != 0 {
+		t.Errorf("expected empty stack, got depth %d", depth)
+	}
+	expectRegister(t, cpu, 0, 1)
+}
@@ -0,0 +1,304 @@
+package chip8
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// stateVersion identifies the layout of State, and is incremented when
+// fields change meaning. Version 2 hex-encodes memory and the display in JSON.
+const stateVersion = 2
+
+// State is a copy of the complete state of a machine, as serialized by
+// MarshalState. Configuration, such as quirks and hooks, is not included.
+//
+// State is encoded as a JSON document whose field names, given by the tags
+// of stateJSON, are stable for tools that consume it. Memory and the display
+// are hex strings, and the stack and the V, RPL and key registers are arrays
+// of numbers.
+type State struct {
+	Version int
+
+	Memory []byte
+	V      [16]byte
+	RPL    [8]byte
+	I      uint16
+	PC     uint16
+	SP     uint16
+	Stack  [16]uint16
+
+	// Contents of each bit-plane, Width x Height pixels
+	Gfx       []byte
+	Gfx2      []byte
+	Width     int
+	Height    int
+	PlaneMask byte
+	DrawFlag  bool
+
+	DelayTimer byte
+	SoundTimer byte
+
+	Key [16]byte
+	// A wait for a key press by FX0A, storing to KeyRegister
+	WaitingForKey  bool
+	KeyRegister    uint16
+	WaitKey        byte
+	WaitKeyPressed bool
+
+	Halted bool
+}
+
+// stateJSON is the JSON document for a State. Fixed size arrays are decoded
+// as slices, so that their lengths can be checked.
+type stateJSON struct {
+	Version int `json:"version"`
+
+	Memory string   `json:"memory"`
+	V      byteList `json:"v"`
+	RPL    byteList `json:"rpl"`
+	I      uint16   `json:"i"`
+	PC     uint16   `json:"pc"`
+	SP     uint16   `json:"sp"`
+	Stack  []uint16 `json:"stack"`
+
+	Gfx       string `json:"gfx"`
+	Gfx2      string `json:"gfx2"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	PlaneMask byte   `json:"planeMask"`
+	DrawFlag  bool   `json:"drawFlag"`
+
+	DelayTimer byte `json:"delayTimer"`
+	SoundTimer byte `json:"soundTimer"`
+
+	Key            byteList `json:"key"`
+	WaitingForKey  bool     `json:"waitingForKey"`
+	KeyRegister    uint16   `json:"keyRegister"`
+	WaitKey        byte     `json:"waitKey"`
+	WaitKeyPressed bool     `json:"waitKeyPressed"`
+
+	Halted bool `json:"halted"`
+}
+
+// byteList is encoded in JSON as an array of numbers, rather than the base64
+// string used for a []byte.
+type byteList []byte
+
+func (b byteList) MarshalJSON() ([]byte, error) {
+	values := make([]int, len(b))
+	for i, v := range b {
+		values[i] = int(v)
+	}
+	return json.Marshal(values)
+}
+
+func (b *byteList) UnmarshalJSON(data []byte) error {
+	var values []int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*b = make(byteList, len(values))
+	for i, v := range values {
+		if v < 0 || v > 0xFF {
+			return fmt.Errorf("value %d out of range for a byte", v)
+		}
+		(*b)[i] = byte(v)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the state as a stateJSON document.
+func (s State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(stateJSON{
+		Version:        s.Version,
+		Memory:         hex.EncodeToString(s.Memory),
+		V:              s.V[:],
+		RPL:            s.RPL[:],
+		I:              s.I,
+		PC:             s.PC,
+		SP:             s.SP,
+		Stack:          s.Stack[:],
+		Gfx:            hex.EncodeToString(s.Gfx),
+		Gfx2:           hex.EncodeToString(s.Gfx2),
+		Width:          s.Width,
+		Height:         s.Height,
+		PlaneMask:      s.PlaneMask,
+		DrawFlag:       s.DrawFlag,
+		DelayTimer:     s.DelayTimer,
+		SoundTimer:     s.SoundTimer,
+		Key:            s.Key[:],
+		WaitingForKey:  s.WaitingForKey,
+		KeyRegister:    s.KeyRegister,
+		WaitKey:        s.WaitKey,
+		WaitKeyPressed: s.WaitKeyPressed,
+		Halted:         s.Halted,
+	})
+}
+
+// UnmarshalJSON decodes a stateJSON document. An error wrapping
+// ErrStateVersion is returned for a document from an unsupported version,
+// and the registers and stack must have exactly the expected number of
+// entries. Other checks are left to Restore.
+func (s *State) UnmarshalJSON(data []byte) error {
+	var version struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return err
+	}
+	if version.Version != stateVersion {
+		return fmt.Errorf("%w: %d", ErrStateVersion, version.Version)
+	}
+
+	var doc stateJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	lengths := []struct {
+		name     string
+		got, len int
+	}{
+		{"v", len(doc.V), len(s.V)},
+		{"rpl", len(doc.RPL), len(s.RPL)},
+		{"stack", len(doc.Stack), len(s.Stack)},
+		{"key", len(doc.Key), len(s.Key)},
+	}
+	for _, l := range lengths {
+		if l.got != l.len {
+			return fmt.Errorf("invalid state: %s has %d entries, expected %d", l.name, l.got, l.len)
+		}
+	}
+	decoded := State{
+		Version:        doc.Version,
+		I:              doc.I,
+		PC:             doc.PC,
+		SP:             doc.SP,
+		Width:          doc.Width,
+		Height:         doc.Height,
+		PlaneMask:      doc.PlaneMask,
+		DrawFlag:       doc.DrawFlag,
+		DelayTimer:     doc.DelayTimer,
+		SoundTimer:     doc.SoundTimer,
+		WaitingForKey:  doc.WaitingForKey,
+		KeyRegister:    doc.KeyRegister,
+		WaitKey:        doc.WaitKey,
+		WaitKeyPressed: doc.WaitKeyPressed,
+		Halted:         doc.Halted,
+	}
+	copy(decoded.V[:], doc.V)
+	copy(decoded.RPL[:], doc.RPL)
+	copy(decoded.Stack[:], doc.Stack)
+	copy(decoded.Key[:], doc.Key)
+	var err error
+	if decoded.Memory, err = hex.DecodeString(doc.Memory); err != nil {
+		return fmt.Errorf("invalid state: memory: %v", err)
+	}
+	if decoded.Gfx, err = hex.DecodeString(doc.Gfx); err != nil {
+		return fmt.Errorf("invalid state: gfx: %v", err)
+	}
+	if decoded.Gfx2, err = hex.DecodeString(doc.Gfx2); err != nil {
+		return fmt.Errorf("invalid state: gfx2: %v", err)
+	}
+	*s = decoded
+	return nil
+}
+
+// MarshalState serializes the state of the machine to JSON.
+func (c *Chip8) MarshalState() ([]byte, error) {
+	return json.Marshal(c.Snapshot())
+}
+
+// UnmarshalState restores machine state serialized by MarshalState.
+// The state is only applied if it is valid. An error wrapping
+// ErrStateVersion is returned for state from an unsupported version.
+func (c *Chip8) UnmarshalState(data []byte) error {
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return c.Restore(s)
+}
+
+// Snapshot returns a copy of the state of the machine, which can be
+// restored later with Restore, for example to implement quick saves.
+func (c *Chip8) Snapshot() State {
+	return State{
+		Version:        stateVersion,
+		Memory:         append([]byte(nil), c.memory[:]...),
+		V:              c.V,
+		RPL:            c.rpl,
+		I:              c.I,
+		PC:             c.pc,
+		SP:             c.sp,
+		Stack:          c.stack,
+		Gfx:            append([]byte(nil), c.gfx...),
+		Gfx2:           append([]byte(nil), c.gfx2...),
+		Width:          c.width,
+		Height:         c.height,
+		PlaneMask:      c.planeMask,
+		DrawFlag:       c.drawFlag,
+		DelayTimer:     c.delayTimer,
+		SoundTimer:     c.soundTimer,
+		Key:            c.key,
+		WaitingForKey:  c.waitingForKey,
+		KeyRegister:    c.keyRegister,
+		WaitKey:        c.waitKey,
+		WaitKeyPressed: c.waitKeyPressed,
+		Halted:         c.halted,
+	}
+}
+
+// Restore returns the machine to a state from Snapshot.
+// An error is returned if s is not valid, in which case the machine is
+// unchanged. Configuration such as quirks and hooks is not affected.
+func (c *Chip8) Restore(s State) error {
+	if s.Version != stateVersion {
+		return fmt.Errorf("%w: %d", ErrStateVersion, s.Version)
+	}
+	if len(s.Memory) != len(c.memory) {
+		return fmt.Errorf("invalid state: %d bytes of memory, expected %d", len(s.Memory), len(c.memory))
+	}
+	if !(s.Width == lowResWidth && s.Height == lowResHeight) && !(s.Width == highResWidth && s.Height == highResHeight) {
+		return fmt.Errorf("invalid state: unsupported resolution %dx%d", s.Width, s.Height)
+	}
+	if len(s.Gfx) != s.Width*s.Height || len(s.Gfx2) != s.Width*s.Height {
+		return fmt.Errorf("invalid state: graphics do not match resolution %dx%d", s.Width, s.Height)
+	}
+	if int(s.SP) > len(c.stack) {
+		return fmt.Errorf("invalid state: stack pointer 0x%X exceeds stack size", s.SP)
+	}
+	if s.PlaneMask > 0x3 {
+		return fmt.Errorf("invalid state: plane mask %d", s.PlaneMask)
+	}
+	if int(s.KeyRegister) >= len(c.V) || int(s.WaitKey) >= len(c.key) {
+		return fmt.Errorf("invalid state: key wait for register %d, key %d", s.KeyRegister, s.WaitKey)
+	}
+
+	copy(c.memory[:], s.Memory)
+	c.V = s.V
+	c.rpl = s.RPL
+	c.I = s.I
+	c.pc = s.PC
+	c.sp = s.SP
+	c.stack = s.Stack
+	if c.flicker != nil && len(s.Gfx) != len(c.gfx) {
+		c.flicker.resize(len(s.Gfx))
+	}
+	c.gfx = append([]byte(nil), s.Gfx...)
+	c.gfx2 = append([]byte(nil), s.Gfx2...)
+	c.width, c.height = s.Width, s.Height
+	c.planeMask = s.PlaneMask
+	c.markAllDirty()
+	c.drawFlag = s.DrawFlag
+	c.delayTimer = s.DelayTimer
+	c.soundTimer = s.SoundTimer
+	c.key = s.Key
+	c.waitingForKey = s.WaitingForKey
+	c.keyRegister = s.KeyRegister
+	c.waitKey = s.WaitKey
+	c.waitKeyPressed = s.WaitKeyPressed
+	c.halted = s.Halted
+	c.clearHistory()
+	return nil
+}
@@ -0,0 +1,303 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+func TestStateJSONRoundTrip(t *testing.T) {
+	// V0 = 5; V1 = 0xA; call 0x208; ...; I = 0x50; draw(V0,V1,5); V0 += 1
+	rom := BuildROM(0x6005, 0x610A, 0x2208, 0x0000, 0xA050, 0xD015, 0x7001)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cpu.delayTimer = 0x20
+	cpu.soundTimer = 0x03
+	cpu.key[7] = 1
+	cpu.rpl[2] = 0x42
+
+	data, err := cpu.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := New(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.UnmarshalState(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected, got := cpu.Snapshot(), restored.Snapshot(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected state %+v, got %+v", expected, got)
+	}
+
+	// Both machines continue identically
+	expected, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := restored.EmulateCycle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected next cycle %+v, got %+v", expected, got)
+	}
+}
+
+func TestUnmarshalStateInvalid(t *testing.T) {
+	valid := initCPU().Snapshot()
+	var tests = []struct {
+		name   string
+		modify func(s *State)
+	}{
+		{name: "version", modify: func(s *State) { s.Version = 99 }},
+		{name: "memory", modify: func(s *State) { s.Memory = s.Memory[:100] }},
+		{name: "resolution", modify: func(s *State) { s.Width = 100 }},
+		{name: "graphics", modify: func(s *State) { s.Gfx = nil }},
+		{name: "stack pointer", modify: func(s *State) { s.SP = 17 }},
+		{name: "plane mask", modify: func(s *State) { s.PlaneMask = 4 }},
+		{name: "key register", modify: func(s *State) { s.KeyRegister = 16 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := valid
+			s.Memory = append([]byte(nil), valid.Memory...)
+			test.modify(&s)
+
+			cpu := initCPU()
+			cpu.V[0] = 0x12
+			if err := cpu.Restore(s); err == nil {
+				t.Fatal("expected an error")
+			}
+			// The machine is unchanged
+			expectRegister(t, cpu, 0, 0x12)
+		})
+	}
+
+	// Documents from other versions, including version 1 which encoded
+	// memory as base64, are rejected
+	for _, doc := range []string{`{"version":1,"memory":"AAAA"}`, `{"version":3}`} {
+		cpu := initCPU()
+		if err := cpu.UnmarshalState([]byte(doc)); !errors.Is(err, ErrStateVersion) {
+			t.Errorf("expected a state version error for %s, got %v", doc, err)
+		}
+	}
+}
+
+func TestUnmarshalStateLengths(t *testing.T) {
+	data, err := initCPU().MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name   string
+		modify func(doc map[string]interface{})
+	}{
+		{name: "17 entry stack", modify: func(doc map[string]interface{}) {
+			doc["stack"] = append(doc["stack"].([]interface{}), 0)
+		}},
+		{name: "15 registers", modify: func(doc map[string]interface{}) {
+			doc["v"] = doc["v"].([]interface{})[:15]
+		}},
+		{name: "register out of range", modify: func(doc map[string]interface{}) {
+			doc["v"].([]interface{})[0] = 256
+		}},
+		{name: "keys", modify: func(doc map[string]interface{}) {
+			doc["key"] = []int{}
+		}},
+		{name: "memory hex", modify: func(doc map[string]interface{}) {
+			doc["memory"] = "zz"
+		}},
+		{name: "memory length", modify: func(doc map[string]interface{}) {
+			doc["memory"] = "00"
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var doc map[string]interface{}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+			test.modify(doc)
+			modified, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cpu := initCPU()
+			cpu.V[0] = 0x12
+			if err := cpu.UnmarshalState(modified); err == nil {
+				t.Fatal("expected an error")
+			}
+			expectRegister(t, cpu, 0, 0x12)
+		})
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	// V0 += 1; I = 0x50; draw(V0,V0,5); call 0x208; return; goto 0x200
+	rom := BuildROM(0x7001, 0xA050, 0xD005, 0x220A, 0x1200, 0x00EE)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.Step(4); err != nil {
+		t.Fatal(err)
+	}
+	saved := cpu.Snapshot()
+
+	if _, err := cpu.Step(7); err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(cpu.Snapshot(), saved) {
+		t.Fatal("expected state to change after more cycles")
+	}
+
+	if err := cpu.Restore(saved); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cpu.Snapshot(); !reflect.DeepEqual(got, saved) {
+		t.Errorf("expected state %+v, got %+v", saved, got)
+	}
+
+	// The snapshot is a copy, unaffected by execution after it is restored
+	if _, err := cpu.Step(3); err != nil {
+		t.Fatal(err)
+	}
+	if saved.PC != 0x20A || saved.V[0] != 1 {
+		t.Errorf("expected snapshot to be unchanged, got pc=0x%X V0=%d", saved.PC, saved.V[0])
+	}
+	expectRegister(t, cpu, 0, 2)
+}
+
+func TestRestoreResolutionWithFlickerDetection(t *testing.T) {
+	hires, err := New(bytes.NewReader(BuildROM(0x00FF)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hires.EmulateCycle(); err != nil {
+		t.Fatal(err)
+	}
+
+	cpu, err := New(bytes.NewReader(nil), WithFlickerDetection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cpu.Restore(hires.Snapshot()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Draw in the bottom right of the high resolution display
+	cpu.V[0] = 100
+	cpu.V[1] = 50
+	cpu.I = defaultFontAddress
+	if _, err := cpu.opcode0xD000(0xD015); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStateJSON(t *testing.T) {
+	cpu := initCPU()
+	cpu.V[3] = 0x33
+	cpu.I = 0x123
+	cpu.memory[0x300] = 0xAB
+	cpu.gfx[5] = 1
+	cpu.key[0xF] = 1
+	cpu.soundTimer = 9
+	state := cpu.Snapshot()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded State
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, state) {
+		t.Errorf("expected state %+v, got %+v", state, decoded)
+	}
+
+	// Memory and the display are encoded as hex
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"memory", "gfx", "gfx2"} {
+		if _, ok := fields[name].(string); !ok {
+			t.Errorf("expected %s to be a string, got %T", name, fields[name])
+		}
+	}
+	if memory := fields["memory"].(string); memory[0x300*2:0x301*2] != "ab" {
+		t.Errorf("expected memory 0x300 to be encoded as ab, got %s", memory[0x300*2:0x301*2])
+	}
+	if v, ok := fields["v"].([]interface{}); !ok || len(v) != 16 || v[3] != float64(0x33) {
+		t.Errorf("expected v to be an array of 16 numbers, got %v", fields["v"])
+	}
+	if fields["i"] != float64(0x123) || fields["soundTimer"] != float64(9) {
+		t.Errorf("unexpected registers: i=%v soundTimer=%v", fields["i"], fields["soundTimer"])
+	}
+}
+
+func TestStateJSONGolden(t *testing.T) {
+	// V0 = 5; V1 = 0xA; call 0x208; ...; I = 0x50; draw(V0,V1,5); V0 += 1
+	rom := BuildROM(0x6005, 0x610A, 0x2208, 0x0000, 0xA050, 0xD015, 0x7001)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cpu.Step(6); err != nil {
+		t.Fatal(err)
+	}
+	cpu.delayTimer = 0x20
+	cpu.key[7] = 1
+
+	data, err := cpu.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := json.Indent(&got, data, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	got.WriteByte('\n')
+
+	golden := filepath.Join("testdata", "state.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), expected) {
+		t.Errorf("state does not match %s, run with -update if the change is intended", golden)
+	}
+
+	// The golden document restores to the same state
+	restored := initCPU()
+	if err := restored.UnmarshalState(expected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Snapshot(), cpu.Snapshot()) {
+		t.Errorf("expected golden state to restore the machine")
+	}
+}
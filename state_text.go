@@ -0,0 +1,284 @@
+package chip8
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DumpStateText writes the machine state to w in a line-based key=value text
+// format that can be edited by hand and read back with LoadStateText.
+//
+// Registers and timers are written in hex. Memory is written as rows of
+// 16 hex bytes keyed by their starting address, with rows of all zeros
+// omitted. The display is written as one row of pixel digits per line,
+// following a hires key indicating the resolution.
+func (c *Chip8) DumpStateText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "pc=0x%03X\n", c.pc)
+	fmt.Fprintf(bw, "i=0x%03X\n", c.I)
+	fmt.Fprintf(bw, "sp=0x%X\n", c.sp)
+	fmt.Fprintf(bw, "v=%s\n", hexBytes(c.V[:]))
+	stack := make([]string, len(c.stack))
+	for i, s := range c.stack {
+		stack[i] = fmt.Sprintf("%03X", s)
+	}
+	fmt.Fprintf(bw, "stack=%s\n", strings.Join(stack, " "))
+	fmt.Fprintf(bw, "delay=0x%02X\n", c.delayTimer)
+	fmt.Fprintf(bw, "sound=0x%02X\n", c.soundTimer)
+	fmt.Fprintf(bw, "key=%s\n", hexBytes(c.key[:]))
+	fmt.Fprintf(bw, "draw=%v\n", c.drawFlag)
+	// Register awaiting a key press for FX0A, or -1 if not waiting
+	waitKey := -1
+	if c.waitingForKey {
+		waitKey = int(c.keyRegister)
+	}
+	fmt.Fprintf(bw, "waitkey=%d\n", waitKey)
+	for addr := 0; addr < len(c.memory); addr += 16 {
+		row := c.memory[addr : addr+16]
+		if isZero(row) {
+			continue
+		}
+		fmt.Fprintf(bw, "mem.%03X=%s\n", addr, hexBytes(row))
+	}
+	fmt.Fprintf(bw, "hires=%v\n", c.width == highResWidth)
+	fmt.Fprintf(bw, "planes=%d\n", c.planeMask)
+	// Pixels are written as color indexes combining both bit-planes
+	buffer := c.GetGraphicsBuffer()
+	for y := 0; y < c.height; y++ {
+		var row strings.Builder
+		for _, p := range buffer[y*c.width : (y+1)*c.width] {
+			row.WriteString(strconv.FormatUint(uint64(p&0xF), 16))
+		}
+		fmt.Fprintf(bw, "gfx.%02d=%s\n", y, row.String())
+	}
+	return bw.Flush()
+}
+
+// LoadStateText restores machine state written by DumpStateText.
+// Blank lines and lines starting with # are ignored, and omitted memory rows
+// are zeroed. The state is only applied if every line is valid.
+func (c *Chip8) LoadStateText(r io.Reader) error {
+	// Parse into a copy so invalid input leaves the machine unchanged
+	s := *c
+	s.memory = [4096]byte{}
+	s.width, s.height = lowResWidth, lowResHeight
+	s.gfx = make([]byte, lowResWidth*lowResHeight)
+	s.gfx2 = make([]byte, lowResWidth*lowResHeight)
+	s.planeMask = 0x1
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.SplitN(text, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("line %d: expected key=value", line)
+		}
+		if err := s.setStateText(parts[0], strings.TrimSpace(parts[1])); err != nil {
+			return fmt.Errorf("line %d: %v: %v", line, parts[0], err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	c.memory = s.memory
+	c.V = s.V
+	c.I = s.I
+	c.pc = s.pc
+	c.sp = s.sp
+	c.stack = s.stack
+	if c.flicker != nil && len(s.gfx) != len(c.gfx) {
+		c.flicker.resize(len(s.gfx))
+	}
+	c.gfx = s.gfx
+	c.gfx2 = s.gfx2
+	c.planeMask = s.planeMask
+	c.width, c.height = s.width, s.height
+	c.markAllDirty()
+	c.delayTimer = s.delayTimer
+	c.soundTimer = s.soundTimer
+	c.key = s.key
+	c.drawFlag = s.drawFlag
+	c.waitingForKey = s.waitingForKey
+	c.keyRegister = s.keyRegister
+	// The text format does not record these, so any previous values are stale
+	c.waitKey = 0
+	c.waitKeyPressed = false
+	c.halted = false
+	c.clearHistory()
+	return nil
+}
+
+// setStateText sets the field named by key from its text value.
+func (c *Chip8) setStateText(key, value string) error {
+	switch {
+	case key == "pc":
+		return parseAddress(value, &c.pc)
+	case key == "i":
+		return parseAddress(value, &c.I)
+	case key == "sp":
+		sp, err := strconv.ParseUint(value, 0, 16)
+		if err != nil {
+			return err
+		}
+		if sp > uint64(len(c.stack)) {
+			return fmt.Errorf("0x%X exceeds stack size", sp)
+		}
+		c.sp = uint16(sp)
+	case key == "v":
+		return parseHexBytes(value, c.V[:])
+	case key == "stack":
+		fields := strings.Fields(value)
+		if len(fields) != len(c.stack) {
+			return fmt.Errorf("expected %d entries, got %d", len(c.stack), len(fields))
+		}
+		for i, field := range fields {
+			if err := parseAddress("0x"+field, &c.stack[i]); err != nil {
+				return err
+			}
+		}
+	case key == "delay":
+		return parseByte(value, &c.delayTimer)
+	case key == "sound":
+		return parseByte(value, &c.soundTimer)
+	case key == "key":
+		return parseHexBytes(value, c.key[:])
+	case key == "draw":
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		c.drawFlag = flag
+	case key == "waitkey":
+		register, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if register < -1 || register >= len(c.V) {
+			return fmt.Errorf("invalid register %d", register)
+		}
+		c.waitingForKey = register >= 0
+		c.keyRegister = 0
+		if c.waitingForKey {
+			c.keyRegister = uint16(register)
+		}
+	case key == "hires":
+		hires, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		// Resizing the display clears it, so this must precede the gfx rows
+		c.width, c.height = lowResWidth, lowResHeight
+		if hires {
+			c.width, c.height = highResWidth, highResHeight
+		}
+		c.gfx = make([]byte, c.width*c.height)
+		c.gfx2 = make([]byte, c.width*c.height)
+	case key == "planes":
+		mask, err := strconv.ParseUint(value, 0, 8)
+		if err != nil {
+			return err
+		}
+		if mask > 0x3 {
+			return fmt.Errorf("invalid plane mask %d", mask)
+		}
+		c.planeMask = byte(mask)
+	case strings.HasPrefix(key, "mem."):
+		addr, err := strconv.ParseUint(strings.TrimPrefix(key, "mem."), 16, 16)
+		if err != nil {
+			return err
+		}
+		if addr%16 != 0 || addr >= uint64(len(c.memory)) {
+			return fmt.Errorf("invalid row address 0x%X", addr)
+		}
+		return parseHexBytes(value, c.memory[addr:addr+16])
+	case strings.HasPrefix(key, "gfx."):
+		y, err := strconv.Atoi(strings.TrimPrefix(key, "gfx."))
+		if err != nil {
+			return err
+		}
+		if y < 0 || y >= c.height {
+			return fmt.Errorf("invalid row %d", y)
+		}
+		if len(value) != c.width {
+			return fmt.Errorf("expected %d pixels, got %d", c.width, len(value))
+		}
+		for x, digit := range value {
+			p, err := strconv.ParseUint(string(digit), 16, 8)
+			if err != nil {
+				return err
+			}
+			if p > 0x3 {
+				return fmt.Errorf("invalid color %d", p)
+			}
+			c.gfx[y*c.width+x] = byte(p & 0x1)
+			c.gfx2[y*c.width+x] = byte(p >> 1)
+		}
+	default:
+		return fmt.Errorf("unknown key")
+	}
+	return nil
+}
+
+func hexBytes(b []byte) string {
+	out := make([]string, len(b))
+	for i, v := range b {
+		out[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(out, " ")
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseHexBytes parses space separated hex bytes, which must exactly fill out.
+func parseHexBytes(value string, out []byte) error {
+	fields := strings.Fields(value)
+	if len(fields) != len(out) {
+		return fmt.Errorf("expected %d bytes, got %d", len(out), len(fields))
+	}
+	for i, field := range fields {
+		b, err := strconv.ParseUint(field, 16, 8)
+		if err != nil {
+			return err
+		}
+		out[i] = byte(b)
+	}
+	return nil
+}
+
+func parseByte(value string, out *byte) error {
+	b, err := strconv.ParseUint(value, 0, 8)
+	if err != nil {
+		return err
+	}
+	*out = byte(b)
+	return nil
+}
+
+// parseAddress parses an address within the 4K address space.
+func parseAddress(value string, out *uint16) error {
+	addr, err := strconv.ParseUint(value, 0, 16)
+	if err != nil {
+		return err
+	}
+	if addr >= 4096 {
+		return fmt.Errorf("address 0x%X out of range", addr)
+	}
+	*out = uint16(addr)
+	return nil
+}
@@ -0,0 +1,167 @@
+package chip8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStateTextRoundTrip(t *testing.T) {
+	// V0 = 5; V1 = 0xA; call 0x208; ...; I = 0x50; draw(V0,V1,5)
+	rom := BuildROM(0x6005, 0x610A, 0x2208, 0x0000, 0xA050, 0xD015)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	cpu.delayTimer = 0x20
+	cpu.soundTimer = 0x03
+	cpu.key[7] = 1
+	cpu.waitingForKey = true
+	cpu.keyRegister = 0xC
+
+	var text bytes.Buffer
+	if err := cpu.DumpStateText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "pc=0x20C\n") {
+		t.Errorf("expected pc in text, got:\n%s", text.String())
+	}
+
+	restored := initCPU()
+	if err := restored.LoadStateText(&text); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if restored.memory != cpu.memory {
+		t.Errorf("memory did not match")
+	}
+	if !bytes.Equal(restored.gfx, cpu.gfx) {
+		t.Errorf("graphics did not match")
+	}
+	if restored.V != cpu.V || restored.I != cpu.I || restored.pc != cpu.pc {
+		t.Errorf("registers did not match")
+	}
+	if restored.sp != cpu.sp || restored.stack != cpu.stack {
+		t.Errorf("stack did not match")
+	}
+	if restored.delayTimer != cpu.delayTimer || restored.soundTimer != cpu.soundTimer {
+		t.Errorf("timers did not match")
+	}
+	if restored.key != cpu.key || restored.drawFlag != cpu.drawFlag {
+		t.Errorf("key or draw flag did not match")
+	}
+	if !restored.waitingForKey || restored.keyRegister != 0xC {
+		t.Errorf("key wait did not match")
+	}
+}
+
+func TestStateTextHighRes(t *testing.T) {
+	cpu := initCPU()
+	cpu.setResolution(highResWidth, highResHeight)
+	cpu.gfx[highResWidth*highResHeight-1] = 1
+
+	var text bytes.Buffer
+	if err := cpu.DumpStateText(&text); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := initCPU()
+	if err := restored.LoadStateText(&text); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w, h := restored.Resolution(); w != highResWidth || h != highResHeight {
+		t.Errorf("expected high resolution, got %dx%d", w, h)
+	}
+	if !bytes.Equal(restored.gfx, cpu.gfx) {
+		t.Errorf("graphics did not match")
+	}
+}
+
+func TestStateTextHighResFlicker(t *testing.T) {
+	cpu := initCPU()
+	cpu.setResolution(highResWidth, highResHeight)
+	var text bytes.Buffer
+	if err := cpu.DumpStateText(&text); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := New(bytes.NewReader(nil), WithFlickerDetection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.halted = true
+	restored.waitKey = 0x5
+	restored.waitKeyPressed = true
+	if err := restored.LoadStateText(&text); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.halted || restored.waitKey != 0 || restored.waitKeyPressed {
+		t.Errorf("expected stale state to be cleared")
+	}
+
+	// Draw in the bottom right of the high resolution display
+	restored.V[0] = 100
+	restored.V[1] = 50
+	restored.I = defaultFontAddress
+	if _, err := restored.opcode0xD000(0xD015); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStateTextPlanes(t *testing.T) {
+	cpu := initCPU()
+	cpu.planeMask = 0x3
+	cpu.gfx[0] = 1
+	cpu.gfx2[0] = 1
+	cpu.gfx2[1] = 1
+
+	var text bytes.Buffer
+	if err := cpu.DumpStateText(&text); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := initCPU()
+	if err := restored.LoadStateText(&text); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.planeMask != 0x3 {
+		t.Errorf("expected plane mask 3, got %d", restored.planeMask)
+	}
+	if !bytes.Equal(restored.gfx, cpu.gfx) || !bytes.Equal(restored.gfx2, cpu.gfx2) {
+		t.Errorf("graphics planes did not match")
+	}
+}
+
+func TestLoadStateTextInvalid(t *testing.T) {
+	var tests = []struct {
+		name string
+		text string
+	}{
+		{name: "unknown key", text: "foo=1"},
+		{name: "missing value", text: "pc"},
+		{name: "pc out of range", text: "pc=0x1000"},
+		{name: "short registers", text: "v=00 01"},
+		{name: "sp out of range", text: "sp=0x11"},
+		{name: "unaligned memory", text: "mem.201=" + strings.Repeat("00 ", 16)},
+		{name: "bad pixel", text: "gfx.00=" + strings.Repeat("x", 64)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu := initCPU()
+			cpu.V[0] = 9
+			err := cpu.LoadStateText(strings.NewReader("# comment\nv=" + strings.Repeat("00 ", 16) + "\n" + test.text))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), "line 3:") {
+				t.Errorf("expected error on line 3, got %v", err)
+			}
+			expectRegister(t, cpu, 0, 9)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package chip8
+
+// WithFullResults populates the Pseudo, Before and After fields of every
+// Result returned by EmulateCycle.
+// By default, describing each cycle is skipped so that many cycles can be
+// run quickly, and these fields are only populated while a tracer is
+// registered with SetTracer.
+func WithFullResults() Option {
+	return func(c *Chip8) {
+		c.fullResults = true
+	}
+}
+
+// SetTracer registers a function to be called with the Result of every
+// cycle that executes an opcode, including those that return an error.
+// Results are fully populated while a tracer is registered.
+// Passing nil removes any existing tracer.
+func (c *Chip8) SetTracer(tracer func(Result)) {
+	c.tracer = tracer
+}
+
+// SetTraceFilter limits the Results passed to the tracer to those with one
+// of the given opcode types, such as "0xDXYN" or "0x2NNN".
+// Calling SetTraceFilter with no arguments traces every opcode.
+func (c *Chip8) SetTraceFilter(mnemonics ...string) {
+	if len(mnemonics) == 0 {
+		c.traceFilter = nil
+		return
+	}
+	c.traceFilter = make(map[string]struct{}, len(mnemonics))
+	for _, m := range mnemonics {
+		c.traceFilter[m] = struct{}{}
+	}
+}
+
+// trace passes result to the tracer if it matches the filter.
+func (c *Chip8) trace(result Result) {
+	if c.tracer == nil {
+		return
+	}
+	if c.traceFilter != nil {
+		if _, ok := c.traceFilter[result.OpcodeType]; !ok {
+			return
+		}
+	}
+	c.tracer(result)
+}
+
+// WithPseudoCache reuses the pseudocode formatted the first time each opcode
+// is executed, rather than formatting it on every cycle.
+// This reduces allocations for programs that repeatedly execute the same
+// opcodes while Results are being fully populated.
+// The cache is discarded whenever the quirks or machine code behavior that
+// pseudocode depends on change, such as when reading a save state.
+func WithPseudoCache() Option {
+	return func(c *Chip8) {
+		c.pseudoCache = make(map[uint16]string)
+	}
+}
+
+// pseudoCacheKey identifies the configuration that cached pseudocode was
+// formatted under.
+type pseudoCacheKey struct {
+	quirks      Quirks
+	machineCode MachineCodeBehavior
+	machineHook bool
+}
+
+// executeCached runs handler for opcode, using the cached pseudocode
+// if there is any.
+func (c *Chip8) executeCached(handler opcodeHandler, opcode uint16) (Result, error) {
+	key := pseudoCacheKey{
+		quirks:      c.quirks,
+		machineCode: c.machineCode,
+		machineHook: c.onMachineCode != nil,
+	}
+	if key != c.pseudoCacheKey {
+		c.pseudoCache = make(map[uint16]string)
+		c.pseudoCacheKey = key
+	}
+
+	pseudo, ok := c.pseudoCache[opcode]
+	if !ok {
+		result, err := handler(opcode)
+		if err == nil {
+			c.pseudoCache[opcode] = result.Pseudo
+		}
+		return result, err
+	}
+
+	c.pseudoCached = true
+	result, err := handler(opcode)
+	c.pseudoCached = false
+	result.Pseudo = pseudo
+	return result, err
+}
+
+// describing returns true iff Results should be fully populated.
+func (c *Chip8) describing() bool {
+	return c.fullResults || c.tracer != nil
+}
+
+// describe returns a Result with the OpcodeType of opcode, as decoded by the
+// disassembler. Pseudo is only formatted if Results are being fully populated
+// and it is not taken from the cache.
+func (c *Chip8) describe(opcode uint16) Result {
+	opcodeType, p := c.describeOpcode(opcode)
+	result := Result{OpcodeType: opcodeType}
+	if !c.pseudoCached && c.describing() {
+		result.Pseudo = p.String()
+	}
+	return result
+}
@@ -0,0 +1,196 @@
+package chip8
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTraceFilter(t *testing.T) {
+	// I = 0x50; V0 = 1; draw(V0,V0,5); V0 += 1; draw(V0,V0,5)
+	rom := BuildROM(0xA050, 0x6001, 0xD005, 0x7001, 0xD005)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var traced []Result
+	cpu.SetTracer(func(r Result) {
+		traced = append(traced, r)
+	})
+	cpu.SetTraceFilter("0xDXYN")
+
+	for i := 0; i < 5; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(traced) != 2 {
+		t.Fatalf("expected 2 traced cycles, got %d", len(traced))
+	}
+	for i, pc := range []uint16{0x204, 0x208} {
+		expectOpcodeType(t, traced[i], "0xDXYN")
+		if traced[i].Before.PC != pc {
+			t.Errorf("expected draw at 0x%03X, got 0x%03X", pc, traced[i].Before.PC)
+		}
+	}
+
+	// An empty filter traces everything
+	traced = nil
+	cpu.Reset()
+	cpu.SetTraceFilter()
+	for i := 0; i < 5; i++ {
+		if _, err := cpu.EmulateCycle(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(traced) != 5 {
+		t.Errorf("expected 5 traced cycles, got %d", len(traced))
+	}
+}
+
+func TestMinimalResults(t *testing.T) {
+	// V0 = 1; V0 += 1
+	rom := BuildROM(0x6001, 0x7001)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectOpcodeType(t, r, "0x6XNN")
+	if r.Opcode != 0x6001 || r.Pseudo != "" || r.After.PC != 0 {
+		t.Errorf("expected a minimal result, got %+v", r)
+	}
+
+	// Tracing still receives full results
+	var traced []Result
+	cpu.SetTracer(func(r Result) {
+		traced = append(traced, r)
+	})
+	r, err = cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(traced) != 1 {
+		t.Fatalf("expected 1 traced cycle, got %d", len(traced))
+	}
+	if traced[0].Pseudo != "V0 += 0x1" || traced[0].Before.PC != 0x202 || traced[0].After.V[0] != 2 {
+		t.Errorf("expected a full result, got %+v", traced[0])
+	}
+	if !reflect.DeepEqual(r, traced[0]) {
+		t.Errorf("expected the traced result to be returned, got %+v", r)
+	}
+}
+
+func TestFullResults(t *testing.T) {
+	// V0 = 1
+	cpu, err := New(bytes.NewReader(BuildROM(0x6001)), WithFullResults())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pseudo != "V0 = 0x1" || r.Before.PC != 0x200 || r.After.PC != 0x202 || r.After.V[0] != 1 {
+		t.Errorf("expected a full result, got %+v", r)
+	}
+}
+
+func BenchmarkEmulateCycle(b *testing.B) {
+	// V0 += 1; V1 = V0; V1 >>= 1; goto 0x200
+	rom := BuildROM(0x7001, 0x8100, 0x8116, 0x1200)
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "minimal"},
+		{name: "full", opts: []Option{WithFullResults()}},
+		{name: "pseudo cache", opts: []Option{WithFullResults(), WithPseudoCache()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			cpu, err := New(bytes.NewReader(rom), bench.opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := cpu.EmulateCycle(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestPseudoCache(t *testing.T) {
+	// V0 += 1; V1 = V0; goto 0x200
+	rom := BuildROM(0x7001, 0x8100, 0x1200)
+	uncached, err := New(bytes.NewReader(rom), WithFullResults())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := New(bytes.NewReader(rom), WithFullResults(), WithPseudoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		expected, err := uncached.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		r, err := cached.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(r, expected) {
+			t.Errorf("cycle %d: expected %+v, got %+v", i, expected, r)
+		}
+	}
+	if len(cached.pseudoCache) != 3 {
+		t.Errorf("expected 3 cached opcodes, got %d", len(cached.pseudoCache))
+	}
+}
+
+func TestPseudoCacheInvalidated(t *testing.T) {
+	// V0 >>= 1; goto 0x200
+	rom := BuildROM(0x8006, 0x1200)
+	cpu, err := New(bytes.NewReader(rom), WithFullResults(), WithPseudoCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pseudo != "V0=V0=V0>>1" {
+		t.Errorf("unexpected pseudo code %q", r.Pseudo)
+	}
+
+	// Load a save state with the Shift quirk
+	shifted, err := New(bytes.NewReader(rom), WithQuirks(Quirks{Shift: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state bytes.Buffer
+	if err := shifted.WriteSaveState(&state); err != nil {
+		t.Fatal(err)
+	}
+	if err := cpu.ReadSaveState(&state); err != nil {
+		t.Fatal(err)
+	}
+	r, err = cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pseudo != "V0>>=1" {
+		t.Errorf("expected pseudo code for the Shift quirk, got %q", r.Pseudo)
+	}
+}
@@ -0,0 +1,93 @@
+package chip8
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists the reachable instructions of a ROM that could not
+// be decoded. It wraps ErrUnknownOpcode.
+type ValidationError struct {
+	Unknown []Instruction
+}
+
+func (e *ValidationError) Error() string {
+	var opcodes []string
+	for _, in := range e.Unknown {
+		opcodes = append(opcodes, fmt.Sprintf("0x%04X at 0x%03X", in.Opcode, in.Address))
+	}
+	return fmt.Sprintf("%v: %s", ErrUnknownOpcode, strings.Join(opcodes, ", "))
+}
+
+// Unwrap returns ErrUnknownOpcode.
+func (e *ValidationError) Unwrap() error {
+	return ErrUnknownOpcode
+}
+
+// ValidateROM checks that every instruction of a ROM that could be reached
+// from its entry point decodes under the given options, without executing it.
+// Jumps and subroutine calls are followed, and both outcomes of conditional
+// skips are considered. Jumps offset by a register (BNNN) cannot be followed.
+// Opcodes are decoded without regard to register values, so errors that
+// depend on them, such as I leaving too little room for FX55, are not
+// reported.
+// A *ValidationError listing the undecodable instructions is returned if any
+// are reachable.
+func ValidateROM(rom []byte, opts ...Option) error {
+	c := newChip8(opts)
+	defer c.Close()
+	if err := c.Load(rom); err != nil {
+		return err
+	}
+
+	var unknown []Instruction
+	visited := make(map[uint16]bool)
+	pending := []uint16{c.startAddress()}
+	for len(pending) > 0 {
+		addr := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		// Opcodes must fit entirely within memory
+		if visited[addr] || int(addr) > len(c.memory)-2 {
+			continue
+		}
+		visited[addr] = true
+
+		in := c.decode(addr, c.opcodeAt(addr))
+		if in.OpcodeType == "" {
+			unknown = append(unknown, in)
+			continue
+		}
+		pending = append(pending, successors(in)...)
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Address < unknown[j].Address
+	})
+	return &ValidationError{Unknown: unknown}
+}
+
+// successors returns the addresses that may be executed after in.
+func successors(in Instruction) []uint16 {
+	next := in.Address + 2
+	target := in.Opcode & 0x0FFF
+	switch in.Opcode & 0xF000 {
+	case 0x0000:
+		if in.Opcode == 0x00EE {
+			// Returns continue after the call, which is followed separately
+			return nil
+		}
+	case 0x1000:
+		return []uint16{target}
+	case 0x2000:
+		return []uint16{target, next}
+	case 0x3000, 0x4000, 0x5000, 0x9000, 0xE000:
+		return []uint16{next, next + 2}
+	case 0xB000:
+		return nil
+	}
+	return []uint16{next}
+}
@@ -0,0 +1,82 @@
+package chip8
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateROM(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		// if V0 != 1 skip; call 0x208; goto 0x206; return
+		rom := BuildROM(0x3001, 0x2208, 0x1206, 0x1206, 0x00EE)
+		if err := ValidateROM(rom); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unreachable unknown opcode", func(t *testing.T) {
+		// goto 0x204; unknown; goto 0x204
+		rom := BuildROM(0x1204, 0xE1FF, 0x1204)
+		if err := ValidateROM(rom); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("reachable unknown opcodes", func(t *testing.T) {
+		// if V0 != 1 skip; goto 0x208; call 0x20A; goto 0x206; unknown; unknown
+		rom := BuildROM(0x3001, 0x1208, 0x220A, 0x1206, 0xE1FF, 0xF0FF)
+		err := ValidateROM(rom)
+		if !errors.Is(err, ErrUnknownOpcode) {
+			t.Fatalf("expected unknown opcode error, got %v", err)
+		}
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected a *ValidationError, got %T", err)
+		}
+		if len(validationErr.Unknown) != 2 {
+			t.Fatalf("expected 2 unknown opcodes, got %v", validationErr.Unknown)
+		}
+		for i, expected := range []Instruction{{Address: 0x208, Opcode: 0xE1FF}, {Address: 0x20A, Opcode: 0xF0FF}} {
+			got := validationErr.Unknown[i]
+			if got.Address != expected.Address || got.Opcode != expected.Opcode {
+				t.Errorf("expected 0x%04X at 0x%03X, got 0x%04X at 0x%03X", expected.Opcode, expected.Address, got.Opcode, got.Address)
+			}
+		}
+		expectedMessage := "unknown opcode: 0xE1FF at 0x208, 0xF0FF at 0x20A"
+		if err.Error() != expectedMessage {
+			t.Errorf("expected error %q, got %q", expectedMessage, err.Error())
+		}
+	})
+
+	t.Run("machine code", func(t *testing.T) {
+		// call machine code 0x123; goto 0x202
+		rom := BuildROM(0x0123, 0x1202)
+		if err := ValidateROM(rom); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		err := ValidateROM(rom, WithMachineCodeBehavior(MachineCodeError))
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected a *ValidationError, got %v", err)
+		}
+		if len(validationErr.Unknown) != 1 || validationErr.Unknown[0].Address != 0x200 {
+			t.Errorf("expected machine code at 0x200 to be unknown, got %v", validationErr.Unknown)
+		}
+	})
+
+	t.Run("register values", func(t *testing.T) {
+		// V0 = 0xFF; if key V0 pressed skip; goto 0x204
+		rom := BuildROM(0x60FF, 0xE09E, 0x1204)
+		if err := ValidateROM(rom); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("index out of range on another branch", func(t *testing.T) {
+		// if V0 != 0 skip; goto 0x208; I = 0xFFF; goto 0x20A; store V0-V5; goto 0x20A
+		rom := BuildROM(0x3000, 0x1208, 0xAFFF, 0x120A, 0xF555, 0x120A)
+		if err := ValidateROM(rom); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package chip8
+
+// WithWatchdog enables detection of the program counter failing to advance.
+// If the program counter is unchanged after the given number of consecutive
+// cycles, EmulateCycle returns an error wrapping ErrStalled, which is an
+// ErrLimitExceeded.
+// Jumps, calls and returns are ignored, as are FX0A while it waits for a key
+// and DXYN while it waits for the display with the DisplayWait quirk, since
+// these may legitimately leave the program counter where it was.
+func WithWatchdog(cycles int) Option {
+	return func(c *Chip8) {
+		c.watchdogLimit = cycles
+	}
+}
+
+// checkWatchdog updates the count of cycles without the program counter
+// advancing following the execution of opcode from pc.
+func (c *Chip8) checkWatchdog(pc, opcode uint16) error {
+	if c.watchdogLimit <= 0 {
+		return nil
+	}
+	if c.pc != pc || c.waitingForKey || c.quirks.DisplayWait && opcode&0xF000 == 0xD000 || isControlFlow(opcode) {
+		c.stalledCycles = 0
+		return nil
+	}
+	c.stalledCycles++
+	if c.stalledCycles >= c.watchdogLimit {
+		return ErrStalled
+	}
+	return nil
+}
+
+// isControlFlow returns true iff opcode may set the program counter directly.
+func isControlFlow(opcode uint16) bool {
+	switch opcode & 0xF000 {
+	case 0x1000, 0x2000, 0xB000:
+		return true
+	}
+	return opcode == 0x00EE
+}
@@ -0,0 +1,60 @@
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWatchdog(t *testing.T) {
+	// V0 = 1; goto 0x202
+	rom := BuildROM(0x6001, 0x1202)
+
+	t.Run("stalled handler", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(rom), WithWatchdog(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Replace 6XNN with a handler that forgets to advance the program counter
+		cpu.opcodes[0x6000] = func(opcode uint16) (Result, error) {
+			return Result{OpcodeType: "0x6XNN"}, nil
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error on cycle %d: %v", i, err)
+			}
+		}
+		_, err = cpu.EmulateCycle()
+		if !errors.Is(err, ErrStalled) {
+			t.Fatalf("expected stalled error, got %v", err)
+		}
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("expected stalled error to be a limit exceeded error")
+		}
+	})
+
+	t.Run("self jump", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(rom), WithWatchdog(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error on cycle %d: %v", i, err)
+			}
+		}
+	})
+
+	t.Run("waiting for key", func(t *testing.T) {
+		cpu, err := New(bytes.NewReader(BuildROM(0xF00A)), WithWatchdog(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			if _, err := cpu.EmulateCycle(); err != nil {
+				t.Fatalf("unexpected error on cycle %d: %v", i, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package chip8
+
+// AddWatchpoint reports writes to memory at addr by opcodes.
+// The Result of a cycle that writes to a watched address lists the address
+// in WatchpointHits.
+func (c *Chip8) AddWatchpoint(addr uint16) {
+	if c.watchpoints == nil {
+		c.watchpoints = make(map[uint16]struct{})
+	}
+	c.watchpoints[addr] = struct{}{}
+}
+
+// RemoveWatchpoint stops reporting writes to addr.
+func (c *Chip8) RemoveWatchpoint(addr uint16) {
+	delete(c.watchpoints, addr)
+}
+
+// writeMemory stores value at addr, recording a hit if addr is watched.
+// All writes to memory by opcodes should be made through writeMemory.
+func (c *Chip8) writeMemory(addr uint16, value byte) {
+	c.memory[addr] = value
+	if _, ok := c.watchpoints[addr]; ok {
+		c.watchpointHits = append(c.watchpointHits, addr)
+	}
+}
@@ -0,0 +1,63 @@
+package chip8
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestWatchpoint(t *testing.T) {
+	// I = 0x300; V0 = 123; set_BCD(V0); V0 = 0; set_BCD(V0)
+	rom := BuildROM(0xA300, 0x607B, 0xF033, 0x6000, 0xF033)
+	cpu, err := New(bytes.NewReader(rom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu.AddWatchpoint(0x301)
+	cpu.AddWatchpoint(0x302)
+	cpu.AddWatchpoint(0x400)
+
+	var hits [][]uint16
+	for i := 0; i < 3; i++ {
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error on cycle %d: %v", i, err)
+		}
+		hits = append(hits, r.WatchpointHits)
+	}
+	expected := [][]uint16{nil, nil, {0x301, 0x302}}
+	if !reflect.DeepEqual(hits, expected) {
+		t.Errorf("expected hits %v, got %v", expected, hits)
+	}
+
+	// Removed watchpoints are no longer reported
+	cpu.RemoveWatchpoint(0x301)
+	for i := 0; i < 2; i++ {
+		r, err := cpu.EmulateCycle()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		hits = append(hits, r.WatchpointHits)
+	}
+	if got := hits[4]; !reflect.DeepEqual(got, []uint16{0x302}) {
+		t.Errorf("expected hits [0x302] after removal, got %v", got)
+	}
+}
+
+func TestWatchpointRegisterDump(t *testing.T) {
+	cpu := initCPU()
+	cpu.I = 0x300
+	cpu.AddWatchpoint(0x303)
+	cpu.AddWatchpoint(0x304)
+	// V0-V3 are stored, so only 0x303 is written
+	cpu.memory[cpu.pc] = 0xF3
+	cpu.memory[cpu.pc+1] = 0x55
+
+	r, err := cpu.EmulateCycle()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(r.WatchpointHits, []uint16{0x303}) {
+		t.Errorf("expected hits [0x303], got %v", r.WatchpointHits)
+	}
+}